@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+type Account struct {
+	Id           string `json:"id"`
+	Type         string `json:"type"`
+	Username     string `json:"username"`
+	Server       string `json:"server,omitempty"`
+	ClientToken  string `json:"clientToken,omitempty"`
+	AccessToken  string `json:"accessToken,omitempty"`
+	RefreshToken string `json:"refreshToken,omitempty"`
+	ExpiresAt    int64  `json:"expiresAt,omitempty"`
+	Uuid         string `json:"uuid,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Xuid         string `json:"xuid,omitempty"`
+	ClientId     string `json:"clientId,omitempty"`
+	UserType     string `json:"userType,omitempty"`
+
+	Profiles []YggdrasilProfile `json:"profiles,omitempty"`
+}
+
+func AccountId(loginType string, username string) string {
+	return loginType + ":" + username
+}
+func NewAccount(loginType string, username string) *Account {
+	return &Account{Id: AccountId(loginType, username), Type: loginType, Username: username}
+}
+func (self *Account) AuthResult() *AuthResult {
+	return &AuthResult{Name: self.Name, Uuid: self.Uuid, AccessToken: self.AccessToken, Xuid: self.Xuid, ClientId: self.ClientId, UserType: self.UserType, Server: self.Server, ExpiresAt: self.ExpiresAt}
+}
+func (self *Account) Update(auth *AuthResult) {
+	self.Name = auth.Name
+	self.Uuid = auth.Uuid
+	self.AccessToken = auth.AccessToken
+	self.Xuid = auth.Xuid
+	self.ClientId = auth.ClientId
+	self.UserType = auth.UserType
+	self.Server = auth.Server
+	self.ExpiresAt = auth.ExpiresAt
+}
+func (self *Account) applySession(session *YggdrasilSession) {
+	self.AccessToken = session.AccessToken
+	self.ClientToken = session.ClientToken
+	self.ClientId = session.ClientToken
+	if len(session.AvailableProfiles) > 0 {
+		self.Profiles = session.AvailableProfiles
+	}
+	if session.SelectedProfile != nil {
+		self.Uuid = session.SelectedProfile.Id
+		self.Name = session.SelectedProfile.Name
+	}
+}
+
+type AccountStore struct {
+	Selected string     `json:"selected"`
+	Accounts []*Account `json:"accounts"`
+	path     string
+}
+
+func LoadAccountStore(path string) (*AccountStore, error) {
+	store := &AccountStore{path: path}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(b, store)
+	if err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+func (self *AccountStore) Save() error {
+	b, err := json.MarshalIndent(self, "", "    ")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(self.path), 0700)
+	if err != nil {
+		return err
+	}
+	tmp := self.path + ".tmp"
+	err = os.WriteFile(tmp, b, 0600)
+	if err != nil {
+		return err
+	}
+	err = os.Chmod(tmp, 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, self.path)
+}
+func (self *AccountStore) Get(id string) *Account {
+	for _, account := range self.Accounts {
+		if account.Id == id {
+			return account
+		}
+	}
+	return nil
+}
+func (self *AccountStore) Put(account *Account) {
+	for i, v := range self.Accounts {
+		if v.Id == account.Id {
+			self.Accounts[i] = account
+			return
+		}
+	}
+	self.Accounts = append(self.Accounts, account)
+	if self.Selected == "" {
+		self.Selected = account.Id
+	}
+}
+func (self *AccountStore) Remove(id string) {
+	for i, v := range self.Accounts {
+		if v.Id == id {
+			self.Accounts = append(self.Accounts[:i], self.Accounts[i+1:]...)
+			break
+		}
+	}
+	if self.Selected == id {
+		self.Selected = ""
+		if len(self.Accounts) > 0 {
+			self.Selected = self.Accounts[0].Id
+		}
+	}
+}
+func (self *AccountStore) Select(id string) error {
+	if self.Get(id) == nil {
+		return errors.New("no account " + id)
+	}
+	self.Selected = id
+	return nil
+}
+func (self *AccountStore) Default() *Account {
+	return self.Get(self.Selected)
+}
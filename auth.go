@@ -0,0 +1,248 @@
+package main
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+type AuthResult struct {
+	Name        string
+	Uuid        string
+	AccessToken string
+	Xuid        string
+	ClientId    string
+	UserType    string
+	Server      string
+	ExpiresAt   int64
+}
+
+func OfflineUuid(name string) string {
+	sum := md5.Sum([]byte("OfflinePlayer:" + name))
+	sum[6] = sum[6]&0x0f | 0x30
+	sum[8] = sum[8]&0x3f | 0x80
+	return hex.EncodeToString(sum[:])
+}
+func OfflineLogin(name string) *AuthResult {
+	return &AuthResult{Name: name, Uuid: OfflineUuid(name), AccessToken: "0", Xuid: "0", UserType: LegacyLogin.String()}
+}
+func (self *McDownloader) currentAccount() *Account {
+	if self.Accounts == nil {
+		return NewAccount(self.UserLoginType, self.UserName)
+	}
+	if self.UserLoginType == "" {
+		account := self.Accounts.Default()
+		if account != nil {
+			return account
+		}
+	}
+	account := self.Accounts.Get(AccountId(self.UserLoginType, self.UserName))
+	if account != nil {
+		return account
+	}
+	return NewAccount(self.UserLoginType, self.UserName)
+}
+func (self *McDownloader) Login(startname string) (*AuthResult, error) {
+	account := self.currentAccount()
+	var err error
+	switch account.Type {
+	case "littleskin", "yggdrasil":
+		err = self.yggdrasilServer(account)
+		if err != nil {
+			return nil, err
+		}
+		err = self.loginYggdrasil(account, startname)
+	case "offline":
+		if startname == "" {
+			startname = account.Username
+		}
+		account.Update(OfflineLogin(startname))
+	case "Microsoft":
+		err = self.loginMicrosoft(account)
+	default:
+		return nil, errors.New("unsupported login type: " + account.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = self.saveAccount(account)
+	if err != nil {
+		return nil, err
+	}
+	return account.AuthResult(), nil
+}
+func (self *McDownloader) loginYggdrasil(account *Account, startname string) error {
+	client := NewYggdrasilClient(account.Server)
+	err := self.ensureYggdrasilToken(client, account)
+	if err != nil {
+		return err
+	}
+	account.UserType = MojangLogin.String()
+	if startname == "" || startname == account.Name {
+		if account.Uuid != "" {
+			return nil
+		}
+		if len(account.Profiles) == 1 {
+			return self.bindProfile(client, account, account.Profiles[0])
+		}
+		return NewProfileNotSelected(account.Id, account.Profiles)
+	}
+	for _, v := range account.Profiles {
+		if v.Name == startname {
+			return self.bindProfile(client, account, v)
+		}
+	}
+	return errors.New("no name you have")
+}
+func (self *McDownloader) ensureYggdrasilToken(client *YggdrasilClient, account *Account) error {
+	if account.AccessToken != "" {
+		valid, err := client.Validate(account.AccessToken, account.ClientToken)
+		if err == nil && valid {
+			return nil
+		}
+		session, err := client.Refresh(account.AccessToken, account.ClientToken, nil)
+		if err == nil {
+			account.applySession(session)
+			return nil
+		}
+	}
+	return self.authenticateYggdrasil(client, account)
+}
+func (self *McDownloader) authenticateYggdrasil(client *YggdrasilClient, account *Account) error {
+	if self.PassWord == "" {
+		return errors.New("password required for " + account.Id)
+	}
+	session, err := client.Authenticate(account.Username, self.PassWord, RandStringBytes(32))
+	if err != nil {
+		return err
+	}
+	account.Uuid = ""
+	account.Name = ""
+	account.applySession(session)
+	return nil
+}
+func (self *McDownloader) bindProfile(client *YggdrasilClient, account *Account, profile YggdrasilProfile) error {
+	if account.Uuid == profile.Id {
+		return nil
+	}
+	session, err := client.Refresh(account.AccessToken, account.ClientToken, &profile)
+	if err != nil && account.Uuid != "" {
+		err = self.authenticateYggdrasil(client, account)
+		if err != nil {
+			return err
+		}
+		if account.Uuid == profile.Id {
+			return nil
+		}
+		session, err = client.Refresh(account.AccessToken, account.ClientToken, &profile)
+	}
+	if err != nil {
+		return err
+	}
+	account.applySession(session)
+	if account.Uuid != profile.Id {
+		account.Uuid = profile.Id
+		account.Name = profile.Name
+	}
+	return nil
+}
+func (self *McDownloader) yggdrasilServer(account *Account) error {
+	switch account.Type {
+	case "littleskin":
+		account.Server = LittleskinServer
+	case "yggdrasil":
+		if account.Server == "" {
+			server, err := ResolveYggdrasilServer(self.YggdrasilUrl)
+			if err != nil {
+				return err
+			}
+			account.Server = server
+		}
+	default:
+		return errors.New("account " + account.Id + " has no yggdrasil profiles")
+	}
+	return nil
+}
+func (self *McDownloader) yggdrasilAccount() (*Account, *YggdrasilClient, error) {
+	account := self.currentAccount()
+	err := self.yggdrasilServer(account)
+	if err != nil {
+		return nil, nil, err
+	}
+	return account, NewYggdrasilClient(account.Server), nil
+}
+func (self *McDownloader) ListProfiles() ([]YggdrasilProfile, error) {
+	account, client, err := self.yggdrasilAccount()
+	if err != nil {
+		return nil, err
+	}
+	err = self.ensureYggdrasilToken(client, account)
+	if err != nil {
+		return nil, err
+	}
+	if len(account.Profiles) == 0 {
+		err = self.authenticateYggdrasil(client, account)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return account.Profiles, self.saveAccount(account)
+}
+func (self *McDownloader) SelectProfile(idOrName string) error {
+	account, client, err := self.yggdrasilAccount()
+	if err != nil {
+		return err
+	}
+	err = self.ensureYggdrasilToken(client, account)
+	if err != nil {
+		return err
+	}
+	for _, v := range account.Profiles {
+		if v.Id == idOrName || v.Name == idOrName {
+			err = self.bindProfile(client, account, v)
+			if err != nil {
+				return err
+			}
+			account.UserType = MojangLogin.String()
+			account.ClientId = account.ClientToken
+			return self.saveAccount(account)
+		}
+	}
+	return errors.New("no profile " + idOrName)
+}
+func (self *McDownloader) saveAccount(account *Account) error {
+	if self.Accounts == nil {
+		return nil
+	}
+	self.Accounts.Put(account)
+	return self.Accounts.Save()
+}
+func (self *McDownloader) loginMicrosoft(account *Account) error {
+	if self.Microsoft == nil {
+		return errors.New("microsoft auth is not configured")
+	}
+	if account.AccessToken != "" && time.Now().Unix() < account.ExpiresAt-60 {
+		return nil
+	}
+	var auth *AuthResult
+	var token *MicrosoftToken
+	var err error
+	if account.RefreshToken != "" {
+		token, err = self.Microsoft.RefreshToken(account.RefreshToken)
+		if err == nil {
+			auth, err = self.Microsoft.LoginWithToken(token)
+		}
+	}
+	if auth == nil || err != nil {
+		auth, token, err = self.Microsoft.Login()
+		if err != nil {
+			return err
+		}
+	}
+	account.Update(auth)
+	if token.RefreshToken != "" {
+		account.RefreshToken = token.RefreshToken
+	}
+	return nil
+}
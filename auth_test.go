@@ -0,0 +1,22 @@
+package main
+
+import "testing"
+
+func TestOfflineUuid(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Notch", "b50ad385829d3141a2167e7d7539ba7f"},
+		{"Steve", "5627dd98e6be3c21b8a8e92344183641"},
+	}
+	for _, tt := range tests {
+		got := OfflineUuid(tt.name)
+		if got != tt.want {
+			t.Errorf("OfflineUuid(%q) = %s, want %s", tt.name, got, tt.want)
+		}
+		if got[12] != '3' {
+			t.Errorf("OfflineUuid(%q) = %s is not a version 3 uuid", tt.name, got)
+		}
+	}
+}
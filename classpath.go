@@ -0,0 +1,170 @@
+package main
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type ClasspathEntry struct {
+	Name string
+	Path string
+}
+
+type Classpath struct {
+	Entries []ClasspathEntry
+	Client  string
+}
+
+func NewClasspath() *Classpath {
+	return &Classpath{}
+}
+func (self *Classpath) Add(name string, path string) {
+	self.Entries = append(self.Entries, ClasspathEntry{Name: name, Path: path})
+}
+func (self *Classpath) Paths() []string {
+	index := map[string]int{}
+	kept := []ClasspathEntry{}
+	for _, entry := range self.Entries {
+		key, version := mavenKey(entry)
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(kept)
+			kept = append(kept, entry)
+			continue
+		}
+		_, keptVersion := mavenKey(kept[i])
+		if CompareMavenVersion(version, keptVersion) > 0 {
+			kept[i] = entry
+		}
+	}
+	paths := []string{}
+	for _, entry := range kept {
+		if entry.Path == self.Client {
+			continue
+		}
+		paths = append(paths, entry.Path)
+	}
+	if self.Client != "" {
+		paths = append(paths, self.Client)
+	}
+	return paths
+}
+func (self *Classpath) String() string {
+	return strings.Join(self.Paths(), string(filepath.ListSeparator))
+}
+func mavenKey(entry ClasspathEntry) (string, string) {
+	parts := strings.Split(entry.Name, ":")
+	if len(parts) < 3 {
+		return entry.Path, ""
+	}
+	key := parts[0] + ":" + parts[1]
+	if len(parts) > 3 {
+		key += ":" + strings.Join(parts[3:], ":")
+	}
+	return key, parts[2]
+}
+func CompareMavenVersion(a string, b string) int {
+	as := mavenTokens(a)
+	bs := mavenTokens(b)
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var at, bt string
+		if i < len(as) {
+			at = as[i]
+		}
+		if i < len(bs) {
+			bt = bs[i]
+		}
+		if c := compareMavenToken(at, bt); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+func mavenTokens(version string) []string {
+	tokens := []string{}
+	trimZeros := func() {
+		for len(tokens) > 0 && tokens[len(tokens)-1] == "0" {
+			tokens = tokens[:len(tokens)-1]
+		}
+	}
+	push := func(token string) {
+		if token == "" {
+			return
+		}
+		if _, err := strconv.Atoi(token); err != nil {
+			trimZeros()
+		}
+		tokens = append(tokens, token)
+	}
+	start := 0
+	version = strings.ToLower(version)
+	for i := 0; i <= len(version); i++ {
+		if i == len(version) || strings.ContainsRune(".-_+", rune(version[i])) {
+			push(version[start:i])
+			start = i + 1
+			continue
+		}
+		if i > start && isDigit(version[i]) != isDigit(version[i-1]) {
+			push(version[start:i])
+			start = i
+		}
+	}
+	for len(tokens) > 0 {
+		last := tokens[len(tokens)-1]
+		if last != "0" && mavenQualifierRank(last) != mavenQualifierRank("") {
+			break
+		}
+		tokens = tokens[:len(tokens)-1]
+	}
+	return tokens
+}
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+func mavenQualifierRank(qualifier string) int {
+	switch qualifier {
+	case "alpha", "a":
+		return 1
+	case "beta", "b":
+		return 2
+	case "milestone", "m":
+		return 3
+	case "rc", "cr", "pre":
+		return 4
+	case "snapshot":
+		return 5
+	case "", "ga", "final", "release":
+		return 6
+	case "sp":
+		return 7
+	}
+	return 8
+}
+func compareMavenToken(a string, b string) int {
+	an, aerr := strconv.Atoi(a)
+	bn, berr := strconv.Atoi(b)
+	switch {
+	case aerr == nil && berr == nil:
+		switch {
+		case an > bn:
+			return 1
+		case an < bn:
+			return -1
+		}
+		return 0
+	case aerr == nil:
+		return 1
+	case berr == nil:
+		return -1
+	}
+	ar := mavenQualifierRank(a)
+	br := mavenQualifierRank(b)
+	switch {
+	case ar > br:
+		return 1
+	case ar < br:
+		return -1
+	}
+	return strings.Compare(a, b)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompareMavenVersion(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.0", "1.0", 0},
+		{"1.10", "1.9", 1},
+		{"2.0", "10.0", -1},
+		{"1.0.1", "1.0", 1},
+		{"9.2", "9.2-beta", 1},
+		{"9.2", "9.2-SNAPSHOT", 1},
+		{"9.2-rc1", "9.2-SNAPSHOT", -1},
+		{"9.2-rc1", "9.2-rc2", -1},
+		{"1.0", "1.0.0", 0},
+		{"1.0-beta", "1-beta", 0},
+		{"1.0.1", "1.0-beta", 1},
+		{"3.2.2", "3.2.1", 1},
+		{"1.0-alpha", "1.0-beta", -1},
+		{"1.0-beta", "1.0-rc", -1},
+		{"1.0-final", "1.0", 0},
+	}
+	for _, tt := range tests {
+		if got := CompareMavenVersion(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareMavenVersion(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestClasspathPaths(t *testing.T) {
+	classpath := NewClasspath()
+	classpath.Client = "versions/1.20.1/1.20.1.jar"
+	classpath.Add("org.ow2.asm:asm:9.3", "asm-9.3.jar")
+	classpath.Add("com.google.guava:guava:31.1-jre", "guava.jar")
+	classpath.Add("org.ow2.asm:asm:9.5", "asm-9.5.jar")
+	classpath.Add("org.lwjgl:lwjgl:3.3.1", "lwjgl.jar")
+	classpath.Add("org.lwjgl:lwjgl:3.3.1:natives-linux", "lwjgl-natives.jar")
+	classpath.Add("org.ow2.asm:asm:9.1", "asm-9.1.jar")
+	classpath.Add("org.ow2.asm:asm:9.5-SNAPSHOT", "asm-9.5-SNAPSHOT.jar")
+	want := []string{"asm-9.5.jar", "guava.jar", "lwjgl.jar", "lwjgl-natives.jar", "versions/1.20.1/1.20.1.jar"}
+	if got := classpath.Paths(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Paths() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+)
+
+const ConfigVersion = 1
+
+type MemoryConfig struct {
+	Min string `json:"min"`
+	Max string `json:"max"`
+}
+
+type WindowConfig struct {
+	Width      int  `json:"width"`
+	Height     int  `json:"height"`
+	Fullscreen bool `json:"fullscreen"`
+}
+
+type AccountsConfig struct {
+	File    string `json:"file"`
+	Default string `json:"default"`
+}
+
+type Config struct {
+	Version     int               `json:"version"`
+	Source      string            `json:"source"`
+	GameDir     string            `json:"gameDir"`
+	JavaPaths   map[string]string `json:"javaPaths"`
+	Memory      MemoryConfig      `json:"memory"`
+	Window      WindowConfig      `json:"window"`
+	JvmArgs     []string          `json:"jvmArgs"`
+	GameArgs    []string          `json:"gameArgs"`
+	Accounts    AccountsConfig    `json:"accounts"`
+	Concurrency int               `json:"concurrency"`
+	path        string
+}
+
+func DefaultConfig() *Config {
+	return &Config{
+		Version:   ConfigVersion,
+		Source:    Mojang.String(),
+		GameDir:   ".minecraft",
+		JavaPaths: map[string]string{},
+		Memory:    MemoryConfig{Min: "", Max: "auto"},
+		Window:    WindowConfig{Width: 854, Height: 480},
+		JvmArgs:   []string{},
+		GameArgs:  []string{},
+	}
+}
+func ConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "goconsolemcl3", "config.json"), nil
+}
+func LoadDefaultConfig() (*Config, error) {
+	path, err := ConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	if !FileNameIsExist(path) && FileNameIsExist("config.json") {
+		config, err := LoadConfigFrom("config.json")
+		if err != nil {
+			return nil, err
+		}
+		config.path = path
+		return config, config.Save()
+	}
+	return LoadConfigFrom(path)
+}
+func LoadConfigFrom(path string) (*Config, error) {
+	config := DefaultConfig()
+	config.path = path
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return config, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	raw := map[string]interface{}{}
+	err = json.Unmarshal(b, &raw)
+	if err != nil {
+		return nil, NewConfigError("", err.Error())
+	}
+	migrated := MigrateConfig(raw)
+	b, err = json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(b, config)
+	if typeErr, ok := err.(*json.UnmarshalTypeError); ok {
+		return nil, NewConfigError(typeErr.Field, "expected "+typeErr.Type.String()+" but got "+typeErr.Value)
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = config.Validate()
+	if err != nil {
+		return nil, err
+	}
+	if migrated && path != "config.json" {
+		err = config.Save()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return config, nil
+}
+func MigrateConfig(raw map[string]interface{}) bool {
+	version, _ := raw["version"].(float64)
+	if int(version) >= ConfigVersion {
+		return false
+	}
+	if javaversions, ok := raw["javaversions"]; ok {
+		raw["javaPaths"] = javaversions
+		delete(raw, "javaversions")
+	}
+	raw["version"] = ConfigVersion
+	return true
+}
+
+var memoryPattern = regexp.MustCompile(`^\d+[kKmMgG]?$`)
+
+func validateMemory(key string, value string) error {
+	if value != "" && value != "auto" && !memoryPattern.MatchString(value) {
+		return NewConfigError(key, "must be \"auto\" or a size like 512M or 4G")
+	}
+	return nil
+}
+func validateMemoryRange(key string, min string, max string) error {
+	minMb, minOk := MemoryMegabytes(min)
+	maxMb, maxOk := MemoryMegabytes(max)
+	if minOk && maxOk && minMb > maxMb {
+		return NewConfigError(key, "must not be larger than the maximum memory "+max)
+	}
+	return nil
+}
+func validateDimension(key string, value int) error {
+	if value < 0 {
+		return NewConfigError(key, "must not be negative")
+	}
+	return nil
+}
+func (self *Config) Validate() error {
+	if self.Version > ConfigVersion {
+		return NewConfigError("version", "config version "+strconv.Itoa(self.Version)+" is newer than this launcher supports")
+	}
+	switch self.Source {
+	case Mojang.String(), BMCLAPI.String(), Mcbbs.String():
+	default:
+		return NewConfigError("source", "must be one of mojang, bmclapi, mcbbs")
+	}
+	for major, path := range self.JavaPaths {
+		if _, err := strconv.Atoi(major); err != nil {
+			return NewConfigError("javaPaths."+major, "key must be a java major version")
+		}
+		if path == "" {
+			return NewConfigError("javaPaths."+major, "path is empty")
+		}
+	}
+	err := validateMemory("memory.min", self.Memory.Min)
+	if err != nil {
+		return err
+	}
+	err = validateMemory("memory.max", self.Memory.Max)
+	if err != nil {
+		return err
+	}
+	err = validateMemoryRange("memory.min", self.Memory.Min, self.Memory.Max)
+	if err != nil {
+		return err
+	}
+	err = validateDimension("window.width", self.Window.Width)
+	if err != nil {
+		return err
+	}
+	err = validateDimension("window.height", self.Window.Height)
+	if err != nil {
+		return err
+	}
+	if self.Concurrency < 0 {
+		return NewConfigError("concurrency", "must not be negative")
+	}
+	return nil
+}
+func (self *Config) Save() error {
+	b, err := json.MarshalIndent(self, "", "    ")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(self.path), 0755)
+	if err != nil {
+		return err
+	}
+	return WriteBytes(self.path, b)
+}
+func (self *Config) AccountsFile(mcDir string) string {
+	if self.Accounts.File != "" {
+		return self.Accounts.File
+	}
+	return filepath.Join(mcDir, "accounts.json")
+}
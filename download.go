@@ -0,0 +1,185 @@
+package main
+
+import (
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+type DownloadScheduler struct {
+	limit int
+	jobs  chan func() error
+	wg    sync.WaitGroup
+	once  sync.Once
+	mu    sync.Mutex
+	errs  []error
+}
+
+func NewDownloadScheduler(limit int) *DownloadScheduler {
+	if limit <= 0 {
+		limit = DefaultConcurrency()
+	}
+	return &DownloadScheduler{limit: limit, jobs: make(chan func() error)}
+}
+func DefaultConcurrency() int {
+	n := runtime.NumCPU() * 4
+	if n > 64 {
+		n = 64
+	}
+	return n
+}
+func (self *DownloadScheduler) start() {
+	for i := 0; i < self.limit; i++ {
+		self.wg.Add(1)
+		go func() {
+			defer self.wg.Done()
+			for job := range self.jobs {
+				err := job()
+				if err != nil {
+					self.mu.Lock()
+					self.errs = append(self.errs, err)
+					self.mu.Unlock()
+				}
+			}
+		}()
+	}
+}
+func (self *DownloadScheduler) Submit(job func() error) {
+	self.once.Do(self.start)
+	self.jobs <- job
+}
+func (self *DownloadScheduler) Wait() error {
+	self.once.Do(self.start)
+	close(self.jobs)
+	self.wg.Wait()
+	if len(self.errs) == 0 {
+		return nil
+	}
+	return NewDownloadErrors(self.errs)
+}
+func (self *McDownloader) newScheduler() *DownloadScheduler {
+	self.Health()
+	return NewDownloadScheduler(self.Concurrency)
+}
+
+const MaxSourceFailures = 3
+
+type SourceHealth struct {
+	mu       sync.Mutex
+	failures map[Source]int
+}
+
+func NewSourceHealth() *SourceHealth {
+	return &SourceHealth{failures: map[Source]int{}}
+}
+func (self *SourceHealth) Healthy(source Source) bool {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return self.failures[source] < MaxSourceFailures
+}
+func (self *SourceHealth) Fail(source Source) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.failures[source]++
+}
+func (self *SourceHealth) Ok(source Source) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	self.failures[source] = 0
+}
+func (self *SourceHealth) Order(sources []Source) []Source {
+	healthy := []Source{}
+	unhealthy := []Source{}
+	for _, source := range sources {
+		if self.Healthy(source) {
+			healthy = append(healthy, source)
+		} else {
+			unhealthy = append(unhealthy, source)
+		}
+	}
+	return append(healthy, unhealthy...)
+}
+func (self *McDownloader) Health() *SourceHealth {
+	if self.health == nil {
+		self.health = NewSourceHealth()
+	}
+	return self.health
+}
+func (self *McDownloader) sourceChain() []Source {
+	sources := self.Sources
+	if len(sources) == 0 {
+		sources = DefaultSources(self.SourceType)
+	}
+	return self.Health().Order(sources)
+}
+func (self *McDownloader) DownloadWithFailover(filename string, url string, algorithm string, needHash string) error {
+	var errs []error
+	for _, source := range self.sourceChain() {
+		err := DownloadWithHash(filename, source.Rewrite(url), algorithm, needHash)
+		if err == nil {
+			self.Health().Ok(source)
+			return nil
+		}
+		self.Health().Fail(source)
+		errs = append(errs, err)
+	}
+	return NewAllSourcesFailed(url, errs)
+}
+func (self *McDownloader) Fetch(filename string, url string, algorithm string, needHash string, size int64) error {
+	if self.Incremental && IsFileSameHash(filename, algorithm, needHash, size) {
+		return nil
+	}
+	return self.DownloadWithFailover(filename, url, algorithm, needHash)
+}
+func (self *McDownloader) FetchMaven(filename string, url string) error {
+	sidecar := filename + ".sha1"
+	if self.Incremental {
+		local, err := os.ReadFile(sidecar)
+		if err == nil {
+			fields := strings.Fields(string(local))
+			if len(fields) > 0 && IsFileSameHash(filename, "sha1", fields[0], 0) {
+				return nil
+			}
+		}
+	}
+	sha1, err := self.GetByteWithFailover(url + ".sha1")
+	if err != nil {
+		return err
+	}
+	fields := strings.Fields(string(sha1))
+	if len(fields) == 0 {
+		return NewHashNotSame("", url+".sha1")
+	}
+	err = self.Fetch(filename, url, "sha1", fields[0], 0)
+	if err != nil {
+		return err
+	}
+	return WriteString(sidecar, fields[0])
+}
+func (self *McDownloader) GetByteWithFailover(url string) ([]byte, error) {
+	var errs []error
+	for _, source := range self.sourceChain() {
+		b, err := GetByteInInternet(source.Rewrite(url))
+		if err == nil {
+			self.Health().Ok(source)
+			return b, nil
+		}
+		self.Health().Fail(source)
+		errs = append(errs, err)
+	}
+	return nil, NewAllSourcesFailed(url, errs)
+}
+func (self *McDownloader) GetByteWithFailoverAndHash(url string, algorithm string, needHash string) ([]byte, error) {
+	var errs []error
+	for _, source := range self.sourceChain() {
+		b, err := GetByteInInternetWithHash(source.Rewrite(url), algorithm, needHash)
+		if err == nil {
+			self.Health().Ok(source)
+			return b, nil
+		}
+		self.Health().Fail(source)
+		errs = append(errs, err)
+	}
+	return nil, NewAllSourcesFailed(url, errs)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDownloadWithFailoverStalledSource(t *testing.T) {
+	idle := HttpIdleTimeout
+	HttpIdleTimeout = 100 * time.Millisecond
+	defer func() { HttpIdleTimeout = idle }()
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "1024")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("partial"))
+		w.(http.Flusher).Flush()
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+	downloader := &McDownloader{Sources: []Source{Mojang}}
+	done := make(chan error, 1)
+	go func() {
+		done <- downloader.DownloadWithFailover(filepath.Join(t.TempDir(), "file"), server.URL, "sha1", "0000")
+	}()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("stalled download succeeded")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("stalled download did not time out")
+	}
+	if downloader.Health().failures[Mojang] != 1 {
+		t.Fatalf("failures = %d, want 1", downloader.Health().failures[Mojang])
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/tidwall/gjson"
+)
+
+const FabricMeta = "https://meta.fabricmc.net/v2"
+
+func (self *McDownloader) LatestFabricLoader(gameVersion string) (string, error) {
+	b, err := self.GetByteWithFailover(FabricMeta + "/versions/loader/" + gameVersion)
+	if err != nil {
+		return "", err
+	}
+	loaders := gjson.ParseBytes(b)
+	if !loaders.IsArray() {
+		return "", NewNotArray()
+	}
+	for _, v := range loaders.Array() {
+		if v.Get("loader.stable").Bool() {
+			return v.Get("loader.version").String(), nil
+		}
+	}
+	if len(loaders.Array()) > 0 {
+		return loaders.Array()[0].Get("loader.version").String(), nil
+	}
+	return "", NewVersionNotFound("fabric-loader for " + gameVersion)
+}
+func (self *McDownloader) InstallFabric(gameVersion string, loaderVersion string) (string, error) {
+	var err error
+	if loaderVersion == "" {
+		loaderVersion, err = self.LatestFabricLoader(gameVersion)
+		if err != nil {
+			return "", err
+		}
+	}
+	b, err := self.GetByteWithFailover(FabricMeta + "/versions/loader/" + gameVersion + "/" + loaderVersion + "/profile/json")
+	if err != nil {
+		return "", err
+	}
+	id := gameVersion + "-fabric-" + loaderVersion
+	err = self.installLoaderProfile(id, gameVersion, b)
+	if err != nil {
+		return "", err
+	}
+	return id, nil
+}
+func (self *McDownloader) installLoaderProfile(id string, gameVersion string, profileJson []byte) error {
+	profile := map[string]interface{}{}
+	err := json.Unmarshal(profileJson, &profile)
+	if err != nil {
+		return err
+	}
+	profile["id"] = id
+	profile["inheritsFrom"] = gameVersion
+	b, err := json.MarshalIndent(profile, "", "    ")
+	if err != nil {
+		return err
+	}
+	path := VersionJsonPath(self.McDir, id)
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	err = WriteBytes(path, b)
+	if err != nil {
+		return err
+	}
+	err = self.LoadVersion(id)
+	if err != nil {
+		return err
+	}
+	return self.GetLib()
+}
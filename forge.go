@@ -0,0 +1,363 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	ForgeMaven    = "https://maven.minecraftforge.net"
+	NeoForgeMaven = "https://maven.neoforged.net/releases"
+)
+
+func (self *McDownloader) InstallForge(gameVersion string, forgeVersion string) (string, error) {
+	full := gameVersion + "-" + forgeVersion
+	url := ForgeMaven + "/net/minecraftforge/forge/" + full + "/forge-" + full + "-installer.jar"
+	installer := filepath.Join(self.McDir, "installers", "forge-"+full+"-installer.jar")
+	err := self.FetchMaven(installer, url)
+	if err != nil {
+		return "", err
+	}
+	return self.InstallForgeInstaller(installer)
+}
+func (self *McDownloader) InstallNeoForge(neoforgeVersion string) (string, error) {
+	url := NeoForgeMaven + "/net/neoforged/neoforge/" + neoforgeVersion + "/neoforge-" + neoforgeVersion + "-installer.jar"
+	installer := filepath.Join(self.McDir, "installers", "neoforge-"+neoforgeVersion+"-installer.jar")
+	err := self.FetchMaven(installer, url)
+	if err != nil {
+		return "", err
+	}
+	return self.InstallForgeInstaller(installer)
+}
+func (self *McDownloader) InstallForgeInstaller(installer string) (string, error) {
+	z, err := zip.OpenReader(installer)
+	if err != nil {
+		return "", err
+	}
+	defer z.Close()
+	profileByte, err := readZipFile(&z.Reader, "install_profile.json")
+	if err != nil {
+		return "", err
+	}
+	profile := gjson.ParseBytes(profileByte)
+	if profile.Get("versionInfo").Exists() {
+		return self.installLegacyForge(&z.Reader, profile)
+	}
+	versionByte, err := readZipFile(&z.Reader, strings.TrimPrefix(profile.Get("json").String(), "/"))
+	if err != nil {
+		return "", err
+	}
+	id := gjson.GetBytes(versionByte, "id").String()
+	err = self.writeVersionJson(id, versionByte)
+	if err != nil {
+		return "", err
+	}
+	libdir := filepath.Join(self.McDir, "libraries")
+	err = extractZipDir(&z.Reader, "maven/", libdir)
+	if err != nil {
+		return "", err
+	}
+	err = self.downloadLibraries(profile.Get("libraries"))
+	if err != nil {
+		return "", err
+	}
+	err = self.LoadVersion(id)
+	if err != nil {
+		return "", err
+	}
+	err = self.GetClient()
+	if err != nil {
+		return "", err
+	}
+	err = self.GetLib()
+	if err != nil {
+		return "", err
+	}
+	tmp, err := os.MkdirTemp("", "forge-installer")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+	data := map[string]string{
+		"SIDE":              "client",
+		"MINECRAFT_JAR":     self.classpath.Client,
+		"MINECRAFT_VERSION": profile.Get("minecraft").String(),
+		"ROOT":              self.McDir,
+		"INSTALLER":         installer,
+		"LIBRARY_DIR":       libdir,
+	}
+	var dataErr error
+	profile.Get("data").ForEach(func(key, value gjson.Result) bool {
+		v := value.Get("client").String()
+		if strings.HasPrefix(v, "/") {
+			target := filepath.Join(tmp, filepath.FromSlash(v))
+			dataErr = extractZipFile(&z.Reader, strings.TrimPrefix(v, "/"), target)
+			if dataErr != nil {
+				return false
+			}
+			v = target
+		} else {
+			v = forgeValue(v, nil, libdir)
+		}
+		data[key.String()] = v
+		return true
+	})
+	if dataErr != nil {
+		return "", dataErr
+	}
+	java, err := self.FindJava(self.versionJson.Get("javaVersion.majorVersion").Int())
+	if err != nil {
+		return "", err
+	}
+	for _, processor := range profile.Get("processors").Array() {
+		err = runForgeProcessor(java, processor, data, libdir)
+		if err != nil {
+			return "", err
+		}
+	}
+	err = self.LoadVersion(id)
+	if err != nil {
+		return "", err
+	}
+	return id, nil
+}
+func (self *McDownloader) installLegacyForge(z *zip.Reader, profile gjson.Result) (string, error) {
+	versionInfo := profile.Get("versionInfo")
+	id := versionInfo.Get("id").String()
+	universalName := profile.Get("install.path").String()
+	universal := filepath.Join(self.McDir, "libraries", filepath.FromSlash(MavenPath(universalName)))
+	err := extractZipFile(z, profile.Get("install.filePath").String(), universal)
+	if err != nil {
+		return "", err
+	}
+	universalSha1, err := FileSha1(universal)
+	if err != nil {
+		return "", err
+	}
+	version := map[string]interface{}{}
+	err = json.Unmarshal([]byte(versionInfo.Raw), &version)
+	if err != nil {
+		return "", err
+	}
+	if !versionInfo.Get("inheritsFrom").Exists() {
+		version["inheritsFrom"] = profile.Get("install.minecraft").String()
+	}
+	libraries := []interface{}{}
+	for _, v := range versionInfo.Get("libraries").Array() {
+		if v.Get("serverreq").Bool() && !v.Get("clientreq").Bool() {
+			continue
+		}
+		if v.Get("name").String() == universalName {
+			libraries = append(libraries, map[string]interface{}{
+				"name": universalName,
+				"downloads": map[string]interface{}{
+					"artifact": map[string]interface{}{
+						"path": MavenPath(universalName),
+						"url":  "",
+						"sha1": universalSha1,
+					},
+				},
+			})
+			continue
+		}
+		library := map[string]interface{}{}
+		err = json.Unmarshal([]byte(v.Raw), &library)
+		if err != nil {
+			return "", err
+		}
+		libraries = append(libraries, library)
+	}
+	version["libraries"] = libraries
+	b, err := json.Marshal(version)
+	if err != nil {
+		return "", err
+	}
+	err = self.writeVersionJson(id, b)
+	if err != nil {
+		return "", err
+	}
+	err = WriteString(universal+".sha1", universalSha1)
+	if err != nil {
+		return "", err
+	}
+	err = self.LoadVersion(id)
+	if err != nil {
+		return "", err
+	}
+	return id, self.GetLib()
+}
+func (self *McDownloader) writeVersionJson(id string, versionByte []byte) error {
+	path := VersionJsonPath(self.McDir, id)
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	return WriteFmtJsonBytes(path, versionByte)
+}
+func (self *McDownloader) downloadLibraries(libraries gjson.Result) error {
+	libdir := filepath.Join(self.McDir, "libraries")
+	scheduler := self.newScheduler()
+	for _, v := range libraries.Array() {
+		artifact := NewLibraryArtifact(v)
+		if artifact == nil || artifact.Path == "" {
+			continue
+		}
+		path := filepath.Join(libdir, filepath.FromSlash(artifact.Path))
+		if artifact.Url == "" {
+			if !FileNameIsExist(path) {
+				return errors.New("library " + v.Get("name").String() + " has no url and is not installed")
+			}
+			continue
+		}
+		scheduler.Submit(func() error {
+			if artifact.Sha1 == "" {
+				return self.FetchMaven(path, artifact.Url)
+			}
+			return self.Fetch(path, artifact.Url, "sha1", artifact.Sha1, artifact.Size)
+		})
+	}
+	return scheduler.Wait()
+}
+func forgeValue(v string, data map[string]string, libdir string) string {
+	switch {
+	case strings.HasPrefix(v, "[") && strings.HasSuffix(v, "]"):
+		return filepath.Join(libdir, filepath.FromSlash(MavenPath(v[1:len(v)-1])))
+	case strings.HasPrefix(v, "'") && strings.HasSuffix(v, "'"):
+		return v[1 : len(v)-1]
+	case strings.HasPrefix(v, "{") && strings.HasSuffix(v, "}") && data != nil:
+		if d, ok := data[v[1:len(v)-1]]; ok {
+			return d
+		}
+	}
+	return v
+}
+func runForgeProcessor(java string, processor gjson.Result, data map[string]string, libdir string) error {
+	sides := processor.Get("sides")
+	if sides.Exists() {
+		client := false
+		for _, side := range sides.Array() {
+			if side.String() == "client" {
+				client = true
+			}
+		}
+		if !client {
+			return nil
+		}
+	}
+	outputs := map[string]string{}
+	processor.Get("outputs").ForEach(func(key, value gjson.Result) bool {
+		outputs[forgeValue(key.String(), data, libdir)] = forgeValue(value.String(), data, libdir)
+		return true
+	})
+	if len(outputs) > 0 && forgeOutputsValid(outputs) {
+		return nil
+	}
+	jar := filepath.Join(libdir, filepath.FromSlash(MavenPath(processor.Get("jar").String())))
+	mainClass, err := jarMainClass(jar)
+	if err != nil {
+		return err
+	}
+	cp := []string{jar}
+	for _, v := range processor.Get("classpath").Array() {
+		cp = append(cp, filepath.Join(libdir, filepath.FromSlash(MavenPath(v.String()))))
+	}
+	args := []string{"-cp", strings.Join(cp, string(filepath.ListSeparator)), mainClass}
+	for _, v := range processor.Get("args").Array() {
+		args = append(args, forgeValue(v.String(), data, libdir))
+	}
+	println("running processor: " + processor.Get("jar").String())
+	out, err := exec.Command(java, args...).CombinedOutput()
+	if err != nil {
+		return errors.New("processor " + processor.Get("jar").String() + " failed: " + err.Error() + "\n" + string(out))
+	}
+	for path, sha1 := range outputs {
+		if !IsFileSameHash(path, "sha1", sha1, 0) {
+			return errors.New("processor output " + path + " does not match sha1 " + sha1)
+		}
+	}
+	return nil
+}
+func forgeOutputsValid(outputs map[string]string) bool {
+	for path, sha1 := range outputs {
+		if !IsFileSameHash(path, "sha1", sha1, 0) {
+			return false
+		}
+	}
+	return true
+}
+func jarMainClass(jar string) (string, error) {
+	z, err := zip.OpenReader(jar)
+	if err != nil {
+		return "", err
+	}
+	defer z.Close()
+	manifest, err := readZipFile(&z.Reader, "META-INF/MANIFEST.MF")
+	if err != nil {
+		return "", err
+	}
+	scanner := bufio.NewScanner(strings.NewReader(string(manifest)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "Main-Class:") {
+			return strings.TrimSpace(strings.TrimPrefix(line, "Main-Class:")), nil
+		}
+	}
+	return "", errors.New(jar + " has no Main-Class")
+}
+func readZipFile(z *zip.Reader, name string) ([]byte, error) {
+	f, err := z.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+func zipHasFile(z *zip.Reader, name string) bool {
+	for _, f := range z.File {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+func extractZipFile(z *zip.Reader, name string, target string) error {
+	b, err := readZipFile(z, name)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(target), 0755)
+	if err != nil {
+		return err
+	}
+	return WriteBytes(target, b)
+}
+func extractZipDir(z *zip.Reader, prefix string, dir string) error {
+	for _, f := range z.File {
+		if !strings.HasPrefix(f.Name, prefix) || f.FileInfo().IsDir() {
+			continue
+		}
+		name := strings.TrimPrefix(f.Name, prefix)
+		if strings.Contains(name, "..") {
+			return errors.New("illegal path in zip: " + f.Name)
+		}
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		err := os.MkdirAll(filepath.Dir(target), 0755)
+		if err != nil {
+			return err
+		}
+		err = unzipFile(f, target)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
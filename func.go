@@ -1,27 +1,94 @@
 package main
 
 import (
+	"archive/zip"
 	"bytes"
+	"context"
 	"crypto/sha1"
+	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
 	"io"
 	"math/rand"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
-	"runtime"
 	"strings"
+	"sync"
+	"time"
+)
+
+const DownloadRetry = 3
 
-	"github.com/tidwall/gjson"
+const (
+	HttpConnectTimeout        = 15 * time.Second
+	HttpResponseHeaderTimeout = 30 * time.Second
 )
 
+var HttpIdleTimeout = 60 * time.Second
+
+var HttpClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   HttpConnectTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConnsPerHost:   16,
+		TLSHandshakeTimeout:   HttpConnectTimeout,
+		ResponseHeaderTimeout: HttpResponseHeaderTimeout,
+		IdleConnTimeout:       90 * time.Second,
+	},
+}
+
+type idleTimeoutBody struct {
+	body   io.ReadCloser
+	timer  *time.Timer
+	cancel context.CancelFunc
+}
+
+func (self *idleTimeoutBody) Read(p []byte) (int, error) {
+	n, err := self.body.Read(p)
+	self.timer.Reset(HttpIdleTimeout)
+	return n, err
+}
+func (self *idleTimeoutBody) Close() error {
+	self.timer.Stop()
+	self.cancel()
+	return self.body.Close()
+}
+func HttpDo(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithCancel(req.Context())
+	r, err := HttpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	r.Body = &idleTimeoutBody{body: r.Body, timer: time.AfterFunc(HttpIdleTimeout, cancel), cancel: cancel}
+	return r, nil
+}
+func HttpGet(url string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return HttpDo(req)
+}
 func GetByteInInternet(url string) ([]byte, error) {
-	r, err := http.Get(url)
+	r, err := HttpGet(url)
 	if err != nil {
 		return nil, err
 	}
 	defer r.Body.Close()
+	if r.StatusCode != http.StatusOK {
+		return nil, NewBadStatus(url, r.StatusCode)
+	}
 	return io.ReadAll(r.Body)
 }
 func GetByteInInternetWithHash(url string, algorithm string, hashcode string) ([]byte, error) {
@@ -78,6 +145,19 @@ func Sha1Bytes(datas []byte) string {
 	s.Write(datas)
 	return hex.EncodeToString(s.Sum(nil))
 }
+func FileSha1(filename string) (string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	s := sha1.New()
+	_, err = io.Copy(s, f)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(s.Sum(nil)), nil
+}
 func ReplaceByMap(s string, c map[string]string) string {
 	ss := s
 	for k, v := range c {
@@ -92,6 +172,15 @@ func IsBytesSameHash(algorithm string, need string, got []byte) bool {
 	}
 	return false
 }
+func NewHasher(algorithm string) (hash.Hash, error) {
+	switch algorithm {
+	case "sha1":
+		return sha1.New(), nil
+	case "sha256":
+		return sha256.New(), nil
+	}
+	return nil, errors.New("unknown hash algorithm: " + algorithm)
+}
 func FileNameIsExist(filePath string) bool {
 	_, err := os.Stat(filePath)
 	if err != nil {
@@ -99,6 +188,29 @@ func FileNameIsExist(filePath string) bool {
 	}
 	return true
 }
+func IsFileSameHash(filename string, algorithm string, needHash string, size int64) bool {
+	info, err := os.Stat(filename)
+	if err != nil || info.IsDir() {
+		return false
+	}
+	if size > 0 && info.Size() != size {
+		return false
+	}
+	h, err := NewHasher(algorithm)
+	if err != nil {
+		return false
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return false
+	}
+	return needHash == hex.EncodeToString(h.Sum(nil))
+}
 func DownloadFmtJsonWithHash(filename string, url string, algorithm string, needHash string) error {
 	getByte, err := GetByteInInternet(url)
 	if err != nil {
@@ -109,29 +221,101 @@ func DownloadFmtJsonWithHash(filename string, url string, algorithm string, need
 	}
 	dirs, _ := filepath.Split(filename)
 	if !FileNameIsExist(dirs) {
-		err = os.MkdirAll(dirs, 0666)
+		err = os.MkdirAll(dirs, 0755)
 		if err != nil {
 			return err
 		}
 	}
 	return WriteFmtJsonBytes(filename, getByte)
 }
+
+var downloadLocks sync.Map
+
+func lockDownload(filename string) func() {
+	v, _ := downloadLocks.LoadOrStore(filename, &sync.Mutex{})
+	m := v.(*sync.Mutex)
+	m.Lock()
+	return m.Unlock
+}
 func DownloadWithHash(filename string, url string, algorithm string, needHash string) error {
-	getByte, err := GetByteInInternet(url)
+	h, err := NewHasher(algorithm)
 	if err != nil {
 		return err
 	}
-	if !IsBytesSameHash(algorithm, needHash, getByte) {
-		return NewHashNotSame(needHash, "")
-	}
+	unlock := lockDownload(filename)
+	defer unlock()
 	dirs, _ := filepath.Split(filename)
 	if !FileNameIsExist(dirs) {
-		err = os.MkdirAll(dirs, 0666)
+		err = os.MkdirAll(dirs, 0755)
 		if err != nil {
 			return err
 		}
 	}
-	return WriteBytes(filename, getByte)
+	part := filename + ".part"
+	for i := 0; i < DownloadRetry; i++ {
+		var resumed bool
+		resumed, err = downloadPart(part, url, h)
+		if err != nil {
+			continue
+		}
+		got := hex.EncodeToString(h.Sum(nil))
+		if got == needHash {
+			return os.Rename(part, filename)
+		}
+		os.Remove(part)
+		err = NewHashNotSame(needHash, got)
+		if !resumed {
+			return err
+		}
+	}
+	return err
+}
+func downloadPart(part string, url string, h hash.Hash) (bool, error) {
+	h.Reset()
+	f, err := os.OpenFile(part, os.O_RDWR|os.O_CREATE, 0666)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	offset, err := io.Copy(h, f)
+	if err != nil {
+		return false, err
+	}
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return false, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	r, err := HttpDo(req)
+	if err != nil {
+		return false, err
+	}
+	defer r.Body.Close()
+	switch r.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusOK:
+		offset = 0
+		h.Reset()
+		err = f.Truncate(0)
+		if err != nil {
+			return false, err
+		}
+		_, err = f.Seek(0, io.SeekStart)
+		if err != nil {
+			return false, err
+		}
+	case http.StatusRequestedRangeNotSatisfiable:
+		if offset > 0 {
+			return true, nil
+		}
+		return false, NewBadStatus(url, r.StatusCode)
+	default:
+		return false, NewBadStatus(url, r.StatusCode)
+	}
+	_, err = io.Copy(io.MultiWriter(f, h), r.Body)
+	return offset > 0, err
 }
 func GetStrFmtJsonInInternetWithHash(url, algorithm, needHash string) (string, error) {
 	getsB, err := GetByteInInternet(url)
@@ -165,7 +349,6 @@ func AssertsTrue(got bool) {
 	}
 }
 func PostMapGotBytes(url string, header map[string]string, value map[string]interface{}) ([]byte, error) {
-	client := &http.Client{}
 	postValue, err := json.Marshal(&value)
 	if err != nil {
 		return nil, err
@@ -177,59 +360,13 @@ func PostMapGotBytes(url string, header map[string]string, value map[string]inte
 	for k, v := range header {
 		req.Header.Add(k, v)
 	}
-	r, err := client.Do(req)
+	r, err := HttpDo(req)
 	if err != nil {
 		return nil, err
 	}
 	defer r.Body.Close()
 	return io.ReadAll(r.Body)
 }
-func IsRuleSameFrom_gjson_Result(rules gjson.Result, isdomouser bool) bool {
-	if !rules.Exists() {
-		return true
-	}
-	var notsame bool = false
-	for _, vr := range rules.Array() {
-		action := vr.Get("action")
-		if action.Exists() {
-			if action.String() != "allow" {
-				continue
-			}
-		}
-		features := vr.Get("features")
-		if features.Exists() {
-			features.ForEach(func(key, value gjson.Result) bool {
-				if key.String() == "is_demo_user" && value.Bool() == isdomouser {
-					notsame = true
-					return false
-				}
-				if key.String() == "has_custom_resolution" && value.Bool() {
-					notsame = true
-					return false
-				}
-				return true
-			})
-			if notsame {
-				break
-			}
-		}
-		oses := vr.Get("os")
-		if oses.Exists() {
-			oses.ForEach(func(key, value gjson.Result) bool {
-				if key.String() == "name" && value.String() == "windows" {
-					notsame = true
-					return false
-				}
-				if key.String() == "arch" && value.String() == "x"+strings.TrimLeft(runtime.GOARCH, "amd") {
-					notsame = true
-					return false
-				}
-				return true
-			})
-		}
-	}
-	return !notsame
-}
 func ReadString(filename string) (string, error) {
 	f, err := os.OpenFile(filename, os.O_RDWR, 0666)
 	if err != nil {
@@ -254,3 +391,136 @@ func WriteString(filename string, data string) error {
 	}
 	return nil
 }
+func CopyFile(dst string, src string) error {
+	err := os.MkdirAll(filepath.Dir(dst), 0755)
+	if err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	_, err = io.Copy(out, in)
+	return err
+}
+func UnzipWithExclude(zipPath string, dir string, exclude []string) error {
+	z, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return err
+	}
+	defer z.Close()
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	for _, f := range z.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		excluded := false
+		for _, e := range exclude {
+			if strings.HasPrefix(f.Name, e) {
+				excluded = true
+				break
+			}
+		}
+		if excluded {
+			continue
+		}
+		target := filepath.Join(root, filepath.FromSlash(f.Name))
+		if !strings.HasPrefix(target, root+string(filepath.Separator)) {
+			return errors.New("illegal path in zip: " + f.Name)
+		}
+		err = os.MkdirAll(filepath.Dir(target), 0755)
+		if err != nil {
+			return err
+		}
+		err = unzipFile(f, target)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+func unzipFile(f *zip.File, target string) error {
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	_, err = io.Copy(out, r)
+	return err
+}
+func PostFormGotBytes(url string, form url.Values) ([]byte, int, error) {
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r, err := HttpDo(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer r.Body.Close()
+	b, err := io.ReadAll(r.Body)
+	return b, r.StatusCode, err
+}
+func GetByteWithHeader(url string, header map[string]string) ([]byte, int, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	for k, v := range header {
+		req.Header.Add(k, v)
+	}
+	r, err := HttpDo(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer r.Body.Close()
+	b, err := io.ReadAll(r.Body)
+	return b, r.StatusCode, err
+}
+func PostJsonGotBytes(url string, value map[string]interface{}) ([]byte, int, error) {
+	postValue, err := json.Marshal(&value)
+	if err != nil {
+		return nil, 0, err
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(postValue))
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	r, err := HttpDo(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer r.Body.Close()
+	b, err := io.ReadAll(r.Body)
+	return b, r.StatusCode, err
+}
+func DownloadFile(filename string, url string) error {
+	err := os.MkdirAll(filepath.Dir(filename), 0755)
+	if err != nil {
+		return err
+	}
+	part := filename + ".part"
+	for i := 0; i < DownloadRetry; i++ {
+		_, err = downloadPart(part, url, sha1.New())
+		if err == nil {
+			return os.Rename(part, filename)
+		}
+	}
+	return err
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestDownloadWithHashConcurrentSamePath(t *testing.T) {
+	body := bytes.Repeat([]byte("minecraft"), 64*1024)
+	sum := sha1.Sum(body)
+	hashcode := hex.EncodeToString(sum[:])
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(body)
+	}))
+	defer server.Close()
+	filename := filepath.Join(t.TempDir(), "objects", hashcode[:2], hashcode)
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- DownloadWithHash(filename, server.URL, "sha1", hashcode)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, body) {
+		t.Fatalf("downloaded %d bytes, want %d", len(got), len(body))
+	}
+	if _, err := os.Stat(filename + ".part"); !os.IsNotExist(err) {
+		t.Fatalf("part file left behind: %v", err)
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+type JavaRuntime struct {
+	Path    string
+	Home    string
+	Version string
+	Major   int64
+	Arch    string
+}
+
+func javaExecutable() string {
+	if runtime.GOOS == "windows" {
+		return "java.exe"
+	}
+	return "java"
+}
+func JavaSearchDirs() []string {
+	dirs := []string{}
+	switch runtime.GOOS {
+	case "linux":
+		dirs = append(dirs, "/usr/lib/jvm", "/usr/lib64/jvm", "/usr/java", "/opt/java", "/opt/jdk", "/opt/jdks")
+	case "darwin":
+		dirs = append(dirs, "/Library/Java/JavaVirtualMachines", "/System/Library/Java/JavaVirtualMachines", "/opt/homebrew/opt", "/usr/local/opt")
+	case "windows":
+		for _, env := range []string{"ProgramFiles", "ProgramFiles(x86)"} {
+			root := os.Getenv(env)
+			if root == "" {
+				continue
+			}
+			for _, vendor := range []string{"Java", "Eclipse Adoptium", "Eclipse Foundation", "AdoptOpenJDK", "Zulu", "Microsoft", "Amazon Corretto", "BellSoft"} {
+				dirs = append(dirs, filepath.Join(root, vendor))
+			}
+		}
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".jdks"), filepath.Join(home, ".sdkman", "candidates", "java"))
+	}
+	return dirs
+}
+func JavaCandidates() []string {
+	candidates := []string{}
+	if home := os.Getenv("JAVA_HOME"); home != "" {
+		candidates = append(candidates, filepath.Join(home, "bin", javaExecutable()))
+	}
+	if path, err := exec.LookPath(javaExecutable()); err == nil {
+		if real, err := filepath.EvalSymlinks(path); err == nil {
+			path = real
+		}
+		candidates = append(candidates, path)
+	}
+	for _, dir := range JavaSearchDirs() {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			home := filepath.Join(dir, entry.Name())
+			for _, bin := range []string{
+				filepath.Join(home, "bin", javaExecutable()),
+				filepath.Join(home, "Contents", "Home", "bin", javaExecutable()),
+				filepath.Join(home, "libexec", "openjdk.jdk", "Contents", "Home", "bin", javaExecutable()),
+			} {
+				if FileNameIsExist(bin) {
+					candidates = append(candidates, bin)
+				}
+			}
+		}
+	}
+	return candidates
+}
+func ProbeJava(path string) (*JavaRuntime, error) {
+	out, err := exec.Command(path, "-XshowSettings:properties", "-version").CombinedOutput()
+	if err != nil {
+		return nil, err
+	}
+	java := &JavaRuntime{Path: path}
+	for _, line := range strings.Split(string(out), "\n") {
+		line = strings.TrimSpace(line)
+		key, value, ok := strings.Cut(line, " = ")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "java.version":
+			java.Version = value
+		case "java.home":
+			java.Home = value
+		case "os.arch":
+			java.Arch = value
+		}
+	}
+	if java.Version == "" {
+		return nil, NewJavaNotFound(0)
+	}
+	java.Major = JavaMajorVersion(java.Version)
+	return java, nil
+}
+func JavaMajorVersion(version string) int64 {
+	version = strings.TrimPrefix(version, "1.")
+	end := strings.IndexFunc(version, func(r rune) bool {
+		return r < '0' || r > '9'
+	})
+	if end >= 0 {
+		version = version[:end]
+	}
+	major, _ := strconv.ParseInt(version, 10, 64)
+	return major
+}
+func FindJavaRuntimes() []*JavaRuntime {
+	runtimes := []*JavaRuntime{}
+	seen := map[string]bool{}
+	for _, candidate := range JavaCandidates() {
+		if seen[candidate] {
+			continue
+		}
+		seen[candidate] = true
+		java, err := ProbeJava(candidate)
+		if err != nil {
+			continue
+		}
+		runtimes = append(runtimes, java)
+	}
+	return runtimes
+}
+func (self *McDownloader) JavaRuntimes() []*JavaRuntime {
+	if self.javaRuntimes == nil {
+		self.javaRuntimes = FindJavaRuntimes()
+	}
+	return self.javaRuntimes
+}
+func javaArchMatches(arch string) bool {
+	switch runtime.GOARCH {
+	case "amd64":
+		return arch == "amd64" || arch == "x86_64"
+	case "386":
+		return arch == "x86" || arch == "i386"
+	case "arm64":
+		return arch == "aarch64" || arch == "arm64"
+	}
+	return true
+}
+func SelectJavaRuntime(runtimes []*JavaRuntime, majorVersion int64) *JavaRuntime {
+	var best *JavaRuntime
+	score := func(java *JavaRuntime) int64 {
+		s := int64(0)
+		if java.Major == majorVersion {
+			s += 1000
+		} else {
+			s -= java.Major - majorVersion
+		}
+		if javaArchMatches(java.Arch) {
+			s += 100
+		}
+		return s
+	}
+	for _, java := range runtimes {
+		if java.Major < majorVersion {
+			continue
+		}
+		if best == nil || score(java) > score(best) {
+			best = java
+		}
+	}
+	return best
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+const JavaRuntimeManifest = "https://piston-meta.mojang.com/v1/products/java-runtime/2ec0cc96c44e5a76b9c8b7c39df7210883d12871/all.json"
+
+func JavaRuntimePlatform() string {
+	switch runtime.GOOS {
+	case "windows":
+		switch runtime.GOARCH {
+		case "386":
+			return "windows-x86"
+		case "arm64":
+			return "windows-arm64"
+		}
+		return "windows-x64"
+	case "darwin":
+		if runtime.GOARCH == "arm64" {
+			return "mac-os-arm64"
+		}
+		return "mac-os"
+	}
+	if runtime.GOARCH == "386" {
+		return "linux-i386"
+	}
+	return "linux"
+}
+func (self *McDownloader) JavaRuntimeDir(component string) string {
+	return filepath.Join(self.McDir, "runtime", component)
+}
+func (self *McDownloader) JavaRuntimePath(component string) string {
+	dir := self.JavaRuntimeDir(component)
+	if runtime.GOOS == "darwin" {
+		return filepath.Join(dir, "jre.bundle", "Contents", "Home", "bin", "java")
+	}
+	return filepath.Join(dir, "bin", javaExecutable())
+}
+func (self *McDownloader) InstalledJavaRuntime(component string) string {
+	if !FileNameIsExist(filepath.Join(self.JavaRuntimeDir(component), ".version")) {
+		return ""
+	}
+	path := self.JavaRuntimePath(component)
+	if !FileNameIsExist(path) {
+		return ""
+	}
+	return path
+}
+func (self *McDownloader) InstallJavaRuntime(component string) (string, error) {
+	manifest, err := self.GetByteWithFailover(JavaRuntimeManifest)
+	if err != nil {
+		return "", err
+	}
+	entry := gjson.GetBytes(manifest, JavaRuntimePlatform()).Get(component).Get("0")
+	if !entry.Exists() {
+		return "", NewVersionNotFound(component + " for " + JavaRuntimePlatform())
+	}
+	filesByte, err := self.GetByteWithFailoverAndHash(entry.Get("manifest.url").String(), "sha1", entry.Get("manifest.sha1").String())
+	if err != nil {
+		return "", err
+	}
+	dir := self.JavaRuntimeDir(component)
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return "", err
+	}
+	files := gjson.GetBytes(filesByte, "files")
+	if !files.IsObject() {
+		return "", NewNotObj()
+	}
+	links := map[string]string{}
+	scheduler := self.newScheduler()
+	var pathErr error
+	files.ForEach(func(key, value gjson.Result) bool {
+		name := key.String()
+		if strings.Contains(name, "..") {
+			pathErr = NewBadRuntimePath(name)
+			return false
+		}
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		switch value.Get("type").String() {
+		case "directory":
+			pathErr = os.MkdirAll(path, 0755)
+			if pathErr != nil {
+				return false
+			}
+		case "link":
+			links[path] = value.Get("target").String()
+		case "file":
+			raw := value.Get("downloads.raw")
+			executable := value.Get("executable").Bool()
+			scheduler.Submit(func() error {
+				err := self.Fetch(path, raw.Get("url").String(), "sha1", raw.Get("sha1").String(), raw.Get("size").Int())
+				if err != nil {
+					return err
+				}
+				if executable && runtime.GOOS != "windows" {
+					return os.Chmod(path, 0755)
+				}
+				return nil
+			})
+		}
+		return true
+	})
+	err = scheduler.Wait()
+	if pathErr != nil {
+		return "", pathErr
+	}
+	if err != nil {
+		return "", err
+	}
+	for path, target := range links {
+		if current, err := os.Readlink(path); err == nil && current == target {
+			continue
+		}
+		os.Remove(path)
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			return "", err
+		}
+		err = os.Symlink(filepath.FromSlash(target), path)
+		if err != nil {
+			return "", err
+		}
+	}
+	err = WriteString(filepath.Join(dir, ".version"), entry.Get("version.name").String())
+	if err != nil {
+		return "", err
+	}
+	return self.JavaRuntimePath(component), nil
+}
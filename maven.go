@@ -0,0 +1,54 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+func MavenPath(name string) string {
+	ext := "jar"
+	if i := strings.LastIndex(name, "@"); i >= 0 {
+		ext = name[i+1:]
+		name = name[:i]
+	}
+	parts := strings.Split(name, ":")
+	if len(parts) < 3 {
+		return ""
+	}
+	file := parts[1] + "-" + parts[2]
+	if len(parts) > 3 {
+		file += "-" + parts[3]
+	}
+	return strings.ReplaceAll(parts[0], ".", "/") + "/" + parts[1] + "/" + parts[2] + "/" + file + "." + ext
+}
+
+type LibraryArtifact struct {
+	Path string
+	Url  string
+	Sha1 string
+	Size int64
+}
+
+func NewLibraryArtifact(library gjson.Result) *LibraryArtifact {
+	downloads := library.Get("downloads")
+	if downloads.Exists() {
+		artifact := downloads.Get("artifact")
+		if !artifact.Exists() {
+			return nil
+		}
+		return &LibraryArtifact{Path: artifact.Get("path").String(), Url: artifact.Get("url").String(), Sha1: artifact.Get("sha1").String(), Size: artifact.Get("size").Int()}
+	}
+	path := MavenPath(library.Get("name").String())
+	if path == "" {
+		return nil
+	}
+	root := "https://libraries.minecraft.net/"
+	if url := library.Get("url"); url.Exists() {
+		root = url.String()
+	}
+	if !strings.HasSuffix(root, "/") {
+		root += "/"
+	}
+	return &LibraryArtifact{Path: path, Url: root + path, Sha1: library.Get("sha1").String(), Size: library.Get("size").Int()}
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+type DeviceCode struct {
+	DeviceCode      string
+	UserCode        string
+	VerificationUri string
+	Message         string
+	ExpiresIn       int64
+	Interval        int64
+}
+
+type MicrosoftToken struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    int64
+}
+
+type MicrosoftAuth struct {
+	ClientId          string
+	Scope             string
+	DeviceCodeUrl     string
+	TokenUrl          string
+	XboxLiveUrl       string
+	XstsUrl           string
+	MinecraftLoginUrl string
+	ProfileUrl        string
+	OnDeviceCode      func(code *DeviceCode)
+}
+
+func NewMicrosoftAuth(clientId string) *MicrosoftAuth {
+	return &MicrosoftAuth{
+		ClientId:          clientId,
+		Scope:             "XboxLive.signin offline_access",
+		DeviceCodeUrl:     "https://login.microsoftonline.com/consumers/oauth2/v2.0/devicecode",
+		TokenUrl:          "https://login.microsoftonline.com/consumers/oauth2/v2.0/token",
+		XboxLiveUrl:       "https://user.auth.xboxlive.com/user/authenticate",
+		XstsUrl:           "https://xsts.auth.xboxlive.com/xsts/authorize",
+		MinecraftLoginUrl: "https://api.minecraftservices.com/authentication/login_with_xbox",
+		ProfileUrl:        "https://api.minecraftservices.com/minecraft/profile",
+		OnDeviceCode: func(code *DeviceCode) {
+			println(code.Message)
+		},
+	}
+}
+func (self *MicrosoftAuth) Login() (*AuthResult, *MicrosoftToken, error) {
+	code, err := self.RequestDeviceCode()
+	if err != nil {
+		return nil, nil, err
+	}
+	if self.OnDeviceCode != nil {
+		self.OnDeviceCode(code)
+	}
+	token, err := self.PollToken(code)
+	if err != nil {
+		return nil, nil, err
+	}
+	auth, err := self.LoginWithToken(token)
+	if err != nil {
+		return nil, nil, err
+	}
+	return auth, token, nil
+}
+func (self *MicrosoftAuth) LoginWithToken(token *MicrosoftToken) (*AuthResult, error) {
+	xblToken, _, err := self.XboxLive(token.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+	xstsToken, uhs, xuid, err := self.Xsts(xblToken)
+	if err != nil {
+		return nil, err
+	}
+	mcToken, expiresIn, err := self.MinecraftToken(uhs, xstsToken)
+	if err != nil {
+		return nil, err
+	}
+	if xuid == "" {
+		xuid = jwtClaim(mcToken, "xuid")
+	}
+	uuid, name, err := self.Profile(mcToken)
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().Unix() + expiresIn
+	return &AuthResult{Name: name, Uuid: uuid, AccessToken: mcToken, Xuid: xuid, ClientId: self.ClientId, UserType: "msa", ExpiresAt: expiresAt}, nil
+}
+func (self *MicrosoftAuth) RequestDeviceCode() (*DeviceCode, error) {
+	b, _, err := PostFormGotBytes(self.DeviceCodeUrl, url.Values{
+		"client_id": {self.ClientId},
+		"scope":     {self.Scope},
+	})
+	if err != nil {
+		return nil, err
+	}
+	r := gjson.ParseBytes(b)
+	if r.Get("error").Exists() {
+		return nil, NewMicrosoftAuthError(r.Get("error").String(), r.Get("error_description").String())
+	}
+	return &DeviceCode{
+		DeviceCode:      r.Get("device_code").String(),
+		UserCode:        r.Get("user_code").String(),
+		VerificationUri: r.Get("verification_uri").String(),
+		Message:         r.Get("message").String(),
+		ExpiresIn:       r.Get("expires_in").Int(),
+		Interval:        r.Get("interval").Int(),
+	}, nil
+}
+func (self *MicrosoftAuth) PollToken(code *DeviceCode) (*MicrosoftToken, error) {
+	interval := time.Duration(code.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	deadline := time.Now().Add(time.Duration(code.ExpiresIn) * time.Second)
+	for code.ExpiresIn <= 0 || time.Now().Before(deadline) {
+		time.Sleep(interval)
+		b, _, err := PostFormGotBytes(self.TokenUrl, url.Values{
+			"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+			"client_id":   {self.ClientId},
+			"device_code": {code.DeviceCode},
+		})
+		if err != nil {
+			return nil, err
+		}
+		r := gjson.ParseBytes(b)
+		switch r.Get("error").String() {
+		case "":
+			return parseMicrosoftToken(r), nil
+		case "authorization_pending":
+			continue
+		case "slow_down":
+			interval += 5 * time.Second
+			continue
+		default:
+			return nil, NewMicrosoftAuthError(r.Get("error").String(), r.Get("error_description").String())
+		}
+	}
+	return nil, NewMicrosoftAuthError("expired_token", "device code expired")
+}
+func (self *MicrosoftAuth) RefreshToken(refreshToken string) (*MicrosoftToken, error) {
+	b, _, err := PostFormGotBytes(self.TokenUrl, url.Values{
+		"grant_type":    {"refresh_token"},
+		"client_id":     {self.ClientId},
+		"refresh_token": {refreshToken},
+		"scope":         {self.Scope},
+	})
+	if err != nil {
+		return nil, err
+	}
+	r := gjson.ParseBytes(b)
+	if r.Get("error").Exists() {
+		return nil, NewMicrosoftAuthError(r.Get("error").String(), r.Get("error_description").String())
+	}
+	return parseMicrosoftToken(r), nil
+}
+func parseMicrosoftToken(r gjson.Result) *MicrosoftToken {
+	return &MicrosoftToken{
+		AccessToken:  r.Get("access_token").String(),
+		RefreshToken: r.Get("refresh_token").String(),
+		ExpiresIn:    r.Get("expires_in").Int(),
+	}
+}
+func (self *MicrosoftAuth) XboxLive(msToken string) (string, string, error) {
+	b, err := PostMapGotBytes(self.XboxLiveUrl, map[string]string{
+		"Content-Type": "application/json",
+		"Accept":       "application/json",
+	}, map[string]interface{}{
+		"Properties": map[string]interface{}{
+			"AuthMethod": "RPS",
+			"SiteName":   "user.auth.xboxlive.com",
+			"RpsTicket":  "d=" + msToken,
+		},
+		"RelyingParty": "http://auth.xboxlive.com",
+		"TokenType":    "JWT",
+	})
+	if err != nil {
+		return "", "", err
+	}
+	r := gjson.ParseBytes(b)
+	if r.Get("XErr").Exists() {
+		return "", "", NewXboxError(r.Get("XErr").Int(), r.Get("Message").String())
+	}
+	token := r.Get("Token").String()
+	if token == "" {
+		return "", "", errors.New("xbox live returned no token")
+	}
+	return token, r.Get("DisplayClaims.xui.0.uhs").String(), nil
+}
+func (self *MicrosoftAuth) Xsts(xblToken string) (string, string, string, error) {
+	b, err := PostMapGotBytes(self.XstsUrl, map[string]string{
+		"Content-Type": "application/json",
+		"Accept":       "application/json",
+	}, map[string]interface{}{
+		"Properties": map[string]interface{}{
+			"SandboxId":  "RETAIL",
+			"UserTokens": []string{xblToken},
+		},
+		"RelyingParty": "rp://api.minecraftservices.com/",
+		"TokenType":    "JWT",
+	})
+	if err != nil {
+		return "", "", "", err
+	}
+	r := gjson.ParseBytes(b)
+	if r.Get("XErr").Exists() {
+		return "", "", "", NewXboxError(r.Get("XErr").Int(), r.Get("Message").String())
+	}
+	token := r.Get("Token").String()
+	if token == "" {
+		return "", "", "", errors.New("xsts returned no token")
+	}
+	return token, r.Get("DisplayClaims.xui.0.uhs").String(), r.Get("DisplayClaims.xui.0.xid").String(), nil
+}
+func (self *MicrosoftAuth) MinecraftToken(uhs string, xstsToken string) (string, int64, error) {
+	b, err := PostMapGotBytes(self.MinecraftLoginUrl, map[string]string{
+		"Content-Type": "application/json",
+		"Accept":       "application/json",
+	}, map[string]interface{}{
+		"identityToken": "XBL3.0 x=" + uhs + ";" + xstsToken,
+	})
+	if err != nil {
+		return "", 0, err
+	}
+	r := gjson.ParseBytes(b)
+	token := r.Get("access_token").String()
+	if token == "" {
+		return "", 0, errors.New("minecraft services returned no token: " + string(b))
+	}
+	return token, r.Get("expires_in").Int(), nil
+}
+func (self *MicrosoftAuth) Profile(mcToken string) (string, string, error) {
+	b, status, err := GetByteWithHeader(self.ProfileUrl, map[string]string{
+		"Authorization": "Bearer " + mcToken,
+	})
+	if err != nil {
+		return "", "", err
+	}
+	if status == http.StatusNotFound {
+		return "", "", errors.New("this account does not own minecraft")
+	}
+	r := gjson.ParseBytes(b)
+	if status != http.StatusOK || !r.Get("id").Exists() {
+		return "", "", NewBadStatus(self.ProfileUrl, status)
+	}
+	return r.Get("id").String(), r.Get("name").String(), nil
+}
+func jwtClaim(token string, claim string) string {
+	parts := strings.Split(token, ".")
+	if len(parts) < 2 {
+		return ""
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return ""
+	}
+	return gjson.GetBytes(payload, claim).String()
+}
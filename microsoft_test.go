@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestMicrosoftLoginChain(t *testing.T) {
+	var polls int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/devicecode", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.PostForm.Get("client_id") != "client" {
+			t.Errorf("devicecode client_id = %q", r.PostForm.Get("client_id"))
+		}
+		w.Write([]byte(`{"device_code":"dev","user_code":"ABCD","verification_uri":"https://example/link","message":"go","expires_in":60,"interval":1}`))
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.PostForm.Get("device_code") != "dev" {
+			t.Errorf("token device_code = %q", r.PostForm.Get("device_code"))
+		}
+		if atomic.AddInt32(&polls, 1) == 1 {
+			w.Write([]byte(`{"error":"authorization_pending"}`))
+			return
+		}
+		w.Write([]byte(`{"access_token":"msa","refresh_token":"refresh","expires_in":3600}`))
+	})
+	mux.HandleFunc("/xbl", func(w http.ResponseWriter, r *http.Request) {
+		var body struct{ Properties struct{ RpsTicket string } }
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Properties.RpsTicket != "d=msa" {
+			t.Errorf("xbl RpsTicket = %q", body.Properties.RpsTicket)
+		}
+		w.Write([]byte(`{"Token":"xbl","DisplayClaims":{"xui":[{"uhs":"userhash"}]}}`))
+	})
+	mux.HandleFunc("/xsts", func(w http.ResponseWriter, r *http.Request) {
+		var body struct{ Properties struct{ UserTokens []string } }
+		json.NewDecoder(r.Body).Decode(&body)
+		if len(body.Properties.UserTokens) != 1 || body.Properties.UserTokens[0] != "xbl" {
+			t.Errorf("xsts UserTokens = %q", body.Properties.UserTokens)
+		}
+		w.Write([]byte(`{"Token":"xsts","DisplayClaims":{"xui":[{"uhs":"userhash","xid":"2535"}]}}`))
+	})
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		var body struct{ IdentityToken string }
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.IdentityToken != "XBL3.0 x=userhash;xsts" {
+			t.Errorf("identityToken = %q", body.IdentityToken)
+		}
+		w.Write([]byte(`{"access_token":"mc","expires_in":86400}`))
+	})
+	mux.HandleFunc("/profile", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer mc" {
+			t.Errorf("profile Authorization = %q", r.Header.Get("Authorization"))
+		}
+		w.Write([]byte(`{"id":"069a79f444e94726a5befca90e38aaf5","name":"Notch"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	auth := NewMicrosoftAuth("client")
+	auth.DeviceCodeUrl = server.URL + "/devicecode"
+	auth.TokenUrl = server.URL + "/token"
+	auth.XboxLiveUrl = server.URL + "/xbl"
+	auth.XstsUrl = server.URL + "/xsts"
+	auth.MinecraftLoginUrl = server.URL + "/login"
+	auth.ProfileUrl = server.URL + "/profile"
+	var shown *DeviceCode
+	auth.OnDeviceCode = func(code *DeviceCode) { shown = code }
+
+	result, token, err := auth.Login()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shown == nil || shown.UserCode != "ABCD" {
+		t.Fatalf("device code not shown: %+v", shown)
+	}
+	if token.RefreshToken != "refresh" {
+		t.Fatalf("refresh token = %q", token.RefreshToken)
+	}
+	if result.Name != "Notch" || result.Uuid != "069a79f444e94726a5befca90e38aaf5" || result.AccessToken != "mc" || result.Xuid != "2535" || result.UserType != "msa" || result.ClientId != "client" {
+		t.Fatalf("unexpected auth result: %+v", result)
+	}
+}
+
+func TestMicrosoftXboxError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"XErr":2148916233,"Message":""}`))
+	}))
+	defer server.Close()
+	auth := NewMicrosoftAuth("client")
+	auth.XstsUrl = server.URL
+	_, _, _, err := auth.Xsts("xbl")
+	xerr, ok := err.(*XboxError)
+	if !ok || xerr.XErr != 2148916233 {
+		t.Fatalf("err = %v", err)
+	}
+}
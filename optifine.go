@@ -0,0 +1,148 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const OptiFineApi = "https://bmclapi2.bangbang93.com/optifine"
+
+type OptiFineVersion struct {
+	McVersion string `json:"mcversion"`
+	Type      string `json:"type"`
+	Patch     string `json:"patch"`
+	Filename  string `json:"filename"`
+	Forge     string `json:"forge"`
+}
+
+func (self *OptiFineVersion) Name() string {
+	return self.McVersion + "_" + self.Type + "_" + self.Patch
+}
+func (self *OptiFineVersion) Url() string {
+	return OptiFineApi + "/" + self.McVersion + "/" + self.Type + "/" + self.Patch
+}
+func OptiFineVersions(gameVersion string) ([]OptiFineVersion, error) {
+	b, err := GetByteInInternet(OptiFineApi + "/" + gameVersion)
+	if err != nil {
+		return nil, err
+	}
+	versions := []OptiFineVersion{}
+	err = json.Unmarshal(b, &versions)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, NewVersionNotFound("OptiFine for " + gameVersion)
+	}
+	return versions, nil
+}
+func (self *McDownloader) downloadOptiFine(version *OptiFineVersion) (string, error) {
+	installer := filepath.Join(self.McDir, "installers", "OptiFine_"+version.Name()+".jar")
+	if FileNameIsExist(installer) {
+		return installer, nil
+	}
+	err := DownloadFile(installer, version.Url())
+	if err != nil {
+		return "", err
+	}
+	return installer, nil
+}
+func (self *McDownloader) InstallOptiFineMod(version *OptiFineVersion) error {
+	installer, err := self.downloadOptiFine(version)
+	if err != nil {
+		return err
+	}
+	return CopyFile(filepath.Join(self.McDir, "mods", "OptiFine_"+version.Name()+".jar"), installer)
+}
+func (self *McDownloader) InstallOptiFine(version *OptiFineVersion) (string, error) {
+	installer, err := self.downloadOptiFine(version)
+	if err != nil {
+		return "", err
+	}
+	err = self.LoadVersion(version.McVersion)
+	if err != nil {
+		return "", err
+	}
+	err = self.GetClient()
+	if err != nil {
+		return "", err
+	}
+	z, err := zip.OpenReader(installer)
+	if err != nil {
+		return "", err
+	}
+	defer z.Close()
+	libdir := filepath.Join(self.McDir, "libraries")
+	optifineName := "optifine:OptiFine:" + version.Name()
+	optifinePath := MavenPath(optifineName)
+	optifineJar := filepath.Join(libdir, filepath.FromSlash(optifinePath))
+	err = os.MkdirAll(filepath.Dir(optifineJar), 0755)
+	if err != nil {
+		return "", err
+	}
+	if zipHasFile(&z.Reader, "optifine/Patcher.class") {
+		java, err := self.FindJava(self.versionJson.Get("javaVersion.majorVersion").Int())
+		if err != nil {
+			return "", err
+		}
+		out, err := exec.Command(java, "-cp", installer, "optifine.Patcher", self.classpath.Client, installer, optifineJar).CombinedOutput()
+		if err != nil {
+			return "", errors.New("optifine patcher failed: " + err.Error() + "\n" + string(out))
+		}
+	} else {
+		err = CopyFile(optifineJar, installer)
+		if err != nil {
+			return "", err
+		}
+	}
+	libraries := []interface{}{map[string]interface{}{
+		"name":      optifineName,
+		"downloads": map[string]interface{}{"artifact": map[string]interface{}{"path": optifinePath, "url": ""}},
+	}}
+	if wrapper, err := readZipFile(&z.Reader, "launchwrapper-of.txt"); err == nil {
+		wrapperVersion := strings.TrimSpace(string(wrapper))
+		wrapperName := "optifine:launchwrapper-of:" + wrapperVersion
+		wrapperPath := MavenPath(wrapperName)
+		err = extractZipFile(&z.Reader, "launchwrapper-of-"+wrapperVersion+".jar", filepath.Join(libdir, filepath.FromSlash(wrapperPath)))
+		if err != nil {
+			return "", err
+		}
+		libraries = append(libraries, map[string]interface{}{
+			"name":      wrapperName,
+			"downloads": map[string]interface{}{"artifact": map[string]interface{}{"path": wrapperPath, "url": ""}},
+		})
+	} else {
+		libraries = append(libraries, map[string]interface{}{"name": "net.minecraft:launchwrapper:1.12"})
+	}
+	id := version.McVersion + "-OptiFine_" + version.Type + "_" + version.Patch
+	profile := map[string]interface{}{
+		"id":           id,
+		"inheritsFrom": version.McVersion,
+		"type":         self.versionJson.Get("type").String(),
+		"mainClass":    "net.minecraft.launchwrapper.Launch",
+		"libraries":    libraries,
+	}
+	if self.versionJson.Get("arguments").Exists() {
+		profile["arguments"] = map[string]interface{}{"game": []string{"--tweakClass", "optifine.OptiFineTweaker"}}
+	} else {
+		profile["minecraftArguments"] = self.versionJson.Get("minecraftArguments").String() + " --tweakClass optifine.OptiFineTweaker"
+	}
+	b, err := json.Marshal(profile)
+	if err != nil {
+		return "", err
+	}
+	err = self.writeVersionJson(id, b)
+	if err != nil {
+		return "", err
+	}
+	err = self.LoadVersion(id)
+	if err != nil {
+		return "", err
+	}
+	return id, self.GetLib()
+}
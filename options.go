@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+type LaunchOptions struct {
+	MinMemory  string   `json:"minMemory"`
+	MaxMemory  string   `json:"maxMemory"`
+	Fullscreen bool     `json:"fullscreen"`
+	Width      int      `json:"width"`
+	Height     int      `json:"height"`
+	JvmArgs    []string `json:"jvmArgs"`
+	GameArgs   []string `json:"gameArgs"`
+
+	QuickPlayPath         string `json:"quickPlayPath,omitempty"`
+	QuickPlaySingleplayer string `json:"quickPlaySingleplayer,omitempty"`
+	QuickPlayMultiplayer  string `json:"quickPlayMultiplayer,omitempty"`
+	QuickPlayRealms       string `json:"quickPlayRealms,omitempty"`
+
+	StrictPlaceholders bool `json:"strictPlaceholders,omitempty"`
+}
+
+func NewLaunchOptions(config *Config) *LaunchOptions {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	return &LaunchOptions{
+		MinMemory:  config.Memory.Min,
+		MaxMemory:  config.Memory.Max,
+		Fullscreen: config.Window.Fullscreen,
+		Width:      config.Window.Width,
+		Height:     config.Window.Height,
+		JvmArgs:    append([]string{}, config.JvmArgs...),
+		GameArgs:   append([]string{}, config.GameArgs...),
+	}
+}
+func LaunchOptionsPath(mcDir string, id string) string {
+	return filepath.Join(mcDir, "versions", id, "launcher_options.json")
+}
+func LoadLaunchOptions(mcDir string, id string, config *Config) (*LaunchOptions, error) {
+	options := NewLaunchOptions(config)
+	b, err := os.ReadFile(LaunchOptionsPath(mcDir, id))
+	if errors.Is(err, os.ErrNotExist) {
+		return options, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(b, options)
+	if typeErr, ok := err.(*json.UnmarshalTypeError); ok {
+		return nil, NewConfigError(typeErr.Field, "expected "+typeErr.Type.String()+" but got "+typeErr.Value)
+	}
+	if err != nil {
+		return nil, NewConfigError("", err.Error())
+	}
+	err = options.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return options, nil
+}
+func (self *LaunchOptions) Validate() error {
+	err := validateMemory("minMemory", self.MinMemory)
+	if err != nil {
+		return err
+	}
+	err = validateMemory("maxMemory", self.MaxMemory)
+	if err != nil {
+		return err
+	}
+	err = validateMemoryRange("minMemory", self.MinMemory, self.MaxMemory)
+	if err != nil {
+		return err
+	}
+	err = validateDimension("width", self.Width)
+	if err != nil {
+		return err
+	}
+	return validateDimension("height", self.Height)
+}
+func (self *LaunchOptions) Save(mcDir string, id string) error {
+	b, err := json.MarshalIndent(self, "", "    ")
+	if err != nil {
+		return err
+	}
+	return WriteBytes(LaunchOptionsPath(mcDir, id), b)
+}
+func (self *LaunchOptions) CustomResolution() bool {
+	return !self.Fullscreen && self.Width > 0 && self.Height > 0
+}
+func (self *LaunchOptions) QuickPlay(ctx *RuleContext) {
+	ctx.Features["has_quick_plays_support"] = self.QuickPlayPath != ""
+	ctx.Features["is_quick_play_singleplayer"] = self.QuickPlaySingleplayer != ""
+	ctx.Features["is_quick_play_multiplayer"] = self.QuickPlayMultiplayer != ""
+	ctx.Features["is_quick_play_realms"] = self.QuickPlayRealms != ""
+}
+func (self *LaunchOptions) LegacyQuickPlayArgs() ([]string, error) {
+	if self.QuickPlayMultiplayer != "" {
+		host, port := SplitServerAddress(self.QuickPlayMultiplayer)
+		return []string{"--server", host, "--port", port}, nil
+	}
+	if self.QuickPlaySingleplayer != "" {
+		return nil, NewQuickPlayUnsupported("singleplayer")
+	}
+	if self.QuickPlayRealms != "" {
+		return nil, NewQuickPlayUnsupported("realms")
+	}
+	return nil, nil
+}
+func SplitServerAddress(address string) (string, string) {
+	host, port := address, ""
+	if strings.HasPrefix(address, "[") {
+		end := strings.Index(address, "]")
+		if end > 0 {
+			host = address[1:end]
+			port = strings.TrimPrefix(address[end+1:], ":")
+		}
+	} else if i := strings.LastIndex(address, ":"); i >= 0 && !strings.Contains(address[:i], ":") {
+		host, port = address[:i], address[i+1:]
+	}
+	if port == "" {
+		port = "25565"
+	}
+	return host, port
+}
+func (self *LaunchOptions) MemoryArgs() []string {
+	args := []string{}
+	max := self.MaxMemory
+	if max == "auto" {
+		max = AutoMaxMemory()
+		if min, ok := MemoryMegabytes(self.MinMemory); ok {
+			if auto, _ := MemoryMegabytes(max); min > auto {
+				max = self.MinMemory
+			}
+		}
+	}
+	min := self.MinMemory
+	if min == "auto" {
+		min = AutoMinMemory(max)
+	}
+	if min != "" {
+		args = append(args, "-Xms"+min)
+	}
+	if max != "" {
+		args = append(args, "-Xmx"+max)
+	}
+	return args
+}
+func MemoryMegabytes(size string) (uint64, bool) {
+	if !memoryPattern.MatchString(size) {
+		return 0, false
+	}
+	unit := strings.ToLower(size[len(size)-1:])
+	n, err := strconv.ParseUint(strings.TrimRight(size, "kKmMgG"), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	switch unit {
+	case "k":
+		return n / 1024, true
+	case "m":
+		return n, true
+	case "g":
+		return n * 1024, true
+	}
+	return n / 1024 / 1024, true
+}
+func AutoMaxMemory() string {
+	total := SystemMemory() / 1024 / 1024
+	if total == 0 {
+		return "2048M"
+	}
+	max := total / 2
+	if max > 8192 {
+		max = 8192
+	}
+	if max < 1024 {
+		max = 1024
+	}
+	return fmt.Sprintf("%dM", max)
+}
+func AutoMinMemory(max string) string {
+	maxMb, ok := MemoryMegabytes(max)
+	if !ok {
+		maxMb, _ = MemoryMegabytes(AutoMaxMemory())
+	}
+	min := maxMb / 4
+	if min < 256 {
+		min = 256
+	}
+	if min > maxMb {
+		min = maxMb
+	}
+	return fmt.Sprintf("%dM", min)
+}
+func SystemMemory() uint64 {
+	switch runtime.GOOS {
+	case "linux":
+		f, err := os.Open("/proc/meminfo")
+		if err != nil {
+			return 0
+		}
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) >= 2 && fields[0] == "MemTotal:" {
+				kb, _ := strconv.ParseUint(fields[1], 10, 64)
+				return kb * 1024
+			}
+		}
+	case "darwin":
+		b, err := exec.Command("sysctl", "-n", "hw.memsize").Output()
+		if err != nil {
+			return 0
+		}
+		n, _ := strconv.ParseUint(strings.TrimSpace(string(b)), 10, 64)
+		return n
+	case "windows":
+		b, err := exec.Command("powershell", "-NoProfile", "-Command", "(Get-CimInstance Win32_ComputerSystem).TotalPhysicalMemory").Output()
+		if err != nil {
+			return 0
+		}
+		n, _ := strconv.ParseUint(strings.TrimSpace(string(b)), 10, 64)
+		return n
+	}
+	return 0
+}
+func (self *McDownloader) LaunchOptions() (*LaunchOptions, error) {
+	if self.Options != nil {
+		return self.Options, self.Options.Validate()
+	}
+	return LoadLaunchOptions(self.McDir, self.versionJson.Get("id").String(), self.Config)
+}
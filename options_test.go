@@ -0,0 +1,59 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitServerAddress(t *testing.T) {
+	tests := []struct {
+		address    string
+		host, port string
+	}{
+		{"mc.example.com", "mc.example.com", "25565"},
+		{"mc.example.com:25566", "mc.example.com", "25566"},
+		{"mc.example.com:", "mc.example.com", "25565"},
+		{"127.0.0.1:25570", "127.0.0.1", "25570"},
+		{"::1", "::1", "25565"},
+		{"2001:db8::1", "2001:db8::1", "25565"},
+		{"[::1]", "::1", "25565"},
+		{"[::1]:25566", "::1", "25566"},
+		{"[::1]:", "::1", "25565"},
+	}
+	for _, tt := range tests {
+		host, port := SplitServerAddress(tt.address)
+		if host != tt.host || port != tt.port {
+			t.Errorf("SplitServerAddress(%q) = %q, %q; want %q, %q", tt.address, host, port, tt.host, tt.port)
+		}
+	}
+}
+
+func TestMemoryArgs(t *testing.T) {
+	tests := []struct {
+		min, max string
+		want     []string
+	}{
+		{"", "", []string{}},
+		{"512M", "2G", []string{"-Xms512M", "-Xmx2G"}},
+		{"auto", "1024M", []string{"-Xms256M", "-Xmx1024M"}},
+		{"auto", "4G", []string{"-Xms1024M", "-Xmx4G"}},
+		{"auto", "512M", []string{"-Xms256M", "-Xmx512M"}},
+		{"auto", "128M", []string{"-Xms128M", "-Xmx128M"}},
+	}
+	for _, tt := range tests {
+		options := &LaunchOptions{MinMemory: tt.min, MaxMemory: tt.max}
+		if got := options.MemoryArgs(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("MemoryArgs(%q, %q) = %q, want %q", tt.min, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestLaunchOptionsValidateMemoryRange(t *testing.T) {
+	err := (&LaunchOptions{MinMemory: "4G", MaxMemory: "1024M"}).Validate()
+	if configErr, ok := err.(*ConfigError); !ok || configErr.Key != "minMemory" {
+		t.Fatalf("err = %v", err)
+	}
+	if err := (&LaunchOptions{MinMemory: "1G", MaxMemory: "1024M"}).Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
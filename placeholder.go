@@ -0,0 +1,104 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+type LaunchVariables struct {
+	AuthPlayerName        string
+	AuthUuid              string
+	AuthAccessToken       string
+	AuthXuid              string
+	ClientId              string
+	UserType              string
+	UserProperties        string
+	VersionName           string
+	VersionType           string
+	GameDirectory         string
+	AssetsRoot            string
+	GameAssets            string
+	AssetsIndexName       string
+	ResolutionWidth       int
+	ResolutionHeight      int
+	QuickPlayPath         string
+	QuickPlaySingleplayer string
+	QuickPlayMultiplayer  string
+	QuickPlayRealms       string
+	NativesDirectory      string
+	LibraryDirectory      string
+	LauncherName          string
+	LauncherVersion       string
+	Classpath             string
+	ClasspathSeparator    string
+}
+
+func (self *LaunchVariables) Map() map[string]string {
+	return map[string]string{
+		"auth_player_name":      self.AuthPlayerName,
+		"auth_uuid":             self.AuthUuid,
+		"auth_access_token":     self.AuthAccessToken,
+		"auth_session":          "token:" + self.AuthAccessToken + ":" + self.AuthUuid,
+		"auth_xuid":             self.AuthXuid,
+		"clientid":              self.ClientId,
+		"user_type":             self.UserType,
+		"user_properties":       self.UserProperties,
+		"version_name":          self.VersionName,
+		"version_type":          self.VersionType,
+		"game_directory":        self.GameDirectory,
+		"assets_root":           self.AssetsRoot,
+		"game_assets":           self.GameAssets,
+		"assets_index_name":     self.AssetsIndexName,
+		"resolution_width":      strconv.Itoa(self.ResolutionWidth),
+		"resolution_height":     strconv.Itoa(self.ResolutionHeight),
+		"quickPlayPath":         self.QuickPlayPath,
+		"quickPlaySingleplayer": self.QuickPlaySingleplayer,
+		"quickPlayMultiplayer":  self.QuickPlayMultiplayer,
+		"quickPlayRealms":       self.QuickPlayRealms,
+		"natives_directory":     self.NativesDirectory,
+		"library_directory":     self.LibraryDirectory,
+		"launcher_name":         self.LauncherName,
+		"launcher_version":      self.LauncherVersion,
+		"classpath":             self.Classpath,
+		"classpath_separator":   self.ClasspathSeparator,
+	}
+}
+
+func Substitute(arg string, vars map[string]string) (string, []string) {
+	var b strings.Builder
+	var unresolved []string
+	for {
+		start := strings.Index(arg, "${")
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(arg[start:], '}')
+		if end < 0 {
+			break
+		}
+		end += start
+		name := arg[start+2 : end]
+		b.WriteString(arg[:start])
+		if v, ok := vars[name]; ok {
+			b.WriteString(v)
+		} else {
+			unresolved = append(unresolved, name)
+		}
+		arg = arg[end+1:]
+	}
+	b.WriteString(arg)
+	return b.String(), unresolved
+}
+
+func SubstituteArguments(args []string, vars map[string]string) ([]string, []error) {
+	out := make([]string, 0, len(args))
+	var warnings []error
+	for _, arg := range args {
+		s, unresolved := Substitute(arg, vars)
+		for _, name := range unresolved {
+			warnings = append(warnings, NewUnresolvedPlaceholder(name, arg))
+		}
+		out = append(out, s)
+	}
+	return out, warnings
+}
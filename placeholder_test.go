@@ -0,0 +1,44 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSubstitute(t *testing.T) {
+	vars := map[string]string{
+		"game_directory": "/home/u/${weird}",
+		"version_name":   "1.20.1",
+		"empty":          "",
+	}
+	tests := []struct {
+		arg        string
+		want       string
+		unresolved []string
+	}{
+		{"--version", "--version", nil},
+		{"${version_name}", "1.20.1", nil},
+		{"-Dv=${version_name}-x", "-Dv=1.20.1-x", nil},
+		{"${game_directory}|${weird}", "/home/u/${weird}|", []string{"weird"}},
+		{"${empty}${version_name}", "1.20.1", nil},
+		{"${missing}", "", []string{"missing"}},
+		{"${a}${b}", "", []string{"a", "b"}},
+		{"${unterminated", "${unterminated", nil},
+	}
+	for _, tt := range tests {
+		got, unresolved := Substitute(tt.arg, vars)
+		if got != tt.want || !reflect.DeepEqual(unresolved, tt.unresolved) {
+			t.Errorf("Substitute(%q) = %q, %v; want %q, %v", tt.arg, got, unresolved, tt.want, tt.unresolved)
+		}
+	}
+}
+
+func TestSubstituteArguments(t *testing.T) {
+	args, warnings := SubstituteArguments([]string{"--name", "${auth_player_name}", "${nope}"}, map[string]string{"auth_player_name": "Steve"})
+	if !reflect.DeepEqual(args, []string{"--name", "Steve", ""}) {
+		t.Fatalf("args = %q", args)
+	}
+	if len(warnings) != 1 || warnings[0].(*UnresolvedPlaceholder).Name != "nope" {
+		t.Fatalf("warnings = %v", warnings)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"io"
+	"os/exec"
+)
+
+type GameProcess struct {
+	cmd  *exec.Cmd
+	done chan struct{}
+	err  error
+}
+
+func StartGameProcess(java string, args []string, dir string, stdout io.Writer, stderr io.Writer) (*GameProcess, error) {
+	cmd := exec.Command(java, args...)
+	cmd.Dir = dir
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	err := cmd.Start()
+	if err != nil {
+		return nil, err
+	}
+	process := &GameProcess{cmd: cmd, done: make(chan struct{})}
+	go func() {
+		process.err = cmd.Wait()
+		close(process.done)
+	}()
+	return process, nil
+}
+func RedactArgs(args []string) []string {
+	redacted := make([]string, len(args))
+	copy(redacted, args)
+	for i := 1; i < len(redacted); i++ {
+		switch redacted[i-1] {
+		case "--accessToken", "--session":
+			redacted[i] = "********"
+		}
+	}
+	return redacted
+}
+func (self *GameProcess) Pid() int {
+	return self.cmd.Process.Pid
+}
+func (self *GameProcess) Args() []string {
+	return self.cmd.Args
+}
+func (self *GameProcess) Wait() (int, error) {
+	<-self.done
+	if _, ok := self.err.(*exec.ExitError); ok {
+		return self.cmd.ProcessState.ExitCode(), nil
+	}
+	if self.err != nil {
+		return -1, self.err
+	}
+	return self.cmd.ProcessState.ExitCode(), nil
+}
+func (self *GameProcess) Exited() bool {
+	select {
+	case <-self.done:
+		return true
+	default:
+		return false
+	}
+}
+func (self *GameProcess) ExitCode() int {
+	if !self.Exited() {
+		return -1
+	}
+	return self.cmd.ProcessState.ExitCode()
+}
+func (self *GameProcess) Kill() error {
+	if self.Exited() {
+		return nil
+	}
+	return self.cmd.Process.Kill()
+}
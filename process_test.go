@@ -0,0 +1,37 @@
+package main
+
+import (
+	"io"
+	"os/exec"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestGameProcessExitWithoutWait(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not found")
+	}
+	process, err := StartGameProcess(sh, []string{"-c", "exit 3"}, t.TempDir(), io.Discard, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !process.Exited() {
+		if time.Now().After(deadline) {
+			t.Fatal("process never reported exit")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if process.ExitCode() != 3 {
+		t.Fatalf("ExitCode() = %d, want 3", process.ExitCode())
+	}
+	code, err := process.Wait()
+	if err != nil || code != 3 {
+		t.Fatalf("Wait() = %d, %v", code, err)
+	}
+}
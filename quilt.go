@@ -0,0 +1,49 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+const QuiltMeta = "https://meta.quiltmc.org/v3"
+
+func (self *McDownloader) LatestQuiltLoader(gameVersion string) (string, error) {
+	b, err := self.GetByteWithFailover(QuiltMeta + "/versions/loader/" + gameVersion)
+	if err != nil {
+		return "", err
+	}
+	loaders := gjson.ParseBytes(b)
+	if !loaders.IsArray() {
+		return "", NewNotArray()
+	}
+	for _, v := range loaders.Array() {
+		version := v.Get("loader.version").String()
+		if !strings.Contains(version, "-") {
+			return version, nil
+		}
+	}
+	if len(loaders.Array()) > 0 {
+		return loaders.Array()[0].Get("loader.version").String(), nil
+	}
+	return "", NewVersionNotFound("quilt-loader for " + gameVersion)
+}
+func (self *McDownloader) InstallQuilt(gameVersion string, loaderVersion string) (string, error) {
+	var err error
+	if loaderVersion == "" {
+		loaderVersion, err = self.LatestQuiltLoader(gameVersion)
+		if err != nil {
+			return "", err
+		}
+	}
+	b, err := self.GetByteWithFailover(QuiltMeta + "/versions/loader/" + gameVersion + "/" + loaderVersion + "/profile/json")
+	if err != nil {
+		return "", err
+	}
+	id := gameVersion + "-quilt-" + loaderVersion
+	err = self.installLoaderProfile(id, gameVersion, b)
+	if err != nil {
+		return "", err
+	}
+	return id, nil
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+type RuleContext struct {
+	OsName    string
+	OsVersion string
+	OsArch    string
+	Features  map[string]bool
+}
+
+func NewRuleContext() *RuleContext {
+	return &RuleContext{OsName: OsName(), OsVersion: OsVersion(), OsArch: OsArch(), Features: map[string]bool{}}
+}
+func OsName() string {
+	switch runtime.GOOS {
+	case "windows":
+		return "windows"
+	case "darwin":
+		return "osx"
+	default:
+		return "linux"
+	}
+}
+func OsArch() string {
+	switch runtime.GOARCH {
+	case "386":
+		return "x86"
+	case "amd64":
+		return "x86_64"
+	case "arm":
+		return "arm32"
+	default:
+		return runtime.GOARCH
+	}
+}
+func OsVersion() string {
+	switch runtime.GOOS {
+	case "linux":
+		b, err := os.ReadFile("/proc/sys/kernel/osrelease")
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(string(b))
+	case "darwin":
+		b, err := exec.Command("sw_vers", "-productVersion").Output()
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(string(b))
+	case "windows":
+		b, err := exec.Command("cmd", "/c", "ver").Output()
+		if err != nil {
+			return ""
+		}
+		m := regexp.MustCompile(`\d+(\.\d+)+`).Find(b)
+		return string(m)
+	}
+	return ""
+}
+func (self *RuleContext) Allows(rules gjson.Result) bool {
+	if !rules.Exists() || !rules.IsArray() {
+		return true
+	}
+	allow := false
+	for _, rule := range rules.Array() {
+		if !self.matches(rule) {
+			continue
+		}
+		allow = rule.Get("action").String() != "disallow"
+	}
+	return allow
+}
+func (self *RuleContext) matches(rule gjson.Result) bool {
+	oses := rule.Get("os")
+	if oses.Exists() {
+		name := oses.Get("name")
+		if name.Exists() && name.String() != self.OsName {
+			return false
+		}
+		arch := oses.Get("arch")
+		if arch.Exists() && arch.String() != self.OsArch {
+			return false
+		}
+		version := oses.Get("version")
+		if version.Exists() {
+			re, err := regexp.Compile(version.String())
+			if err != nil || !re.MatchString(self.OsVersion) {
+				return false
+			}
+		}
+	}
+	matched := true
+	rule.Get("features").ForEach(func(key, value gjson.Result) bool {
+		if self.Features[key.String()] != value.Bool() {
+			matched = false
+			return false
+		}
+		return true
+	})
+	return matched
+}
+
+const LegacyJvmArguments = `[
+	{"rules": [{"action": "allow", "os": {"name": "osx"}}], "value": ["-XstartOnFirstThread"]},
+	{"rules": [{"action": "allow", "os": {"name": "windows"}}], "value": "-XX:HeapDumpPath=MojangTricksIntelFriendlyPlatformsNeedThisToAvoidCrashes.heapdump"},
+	{"rules": [{"action": "allow", "os": {"name": "windows", "version": "^10\\."}}], "value": ["-Dos.name=Windows 10", "-Dos.version=10.0"]},
+	{"rules": [{"action": "allow", "os": {"arch": "x86"}}], "value": "-Xss1M"},
+	"-Djava.library.path=${natives_directory}",
+	"-Dminecraft.launcher.brand=${launcher_name}",
+	"-Dminecraft.launcher.version=${launcher_version}",
+	"-cp",
+	"${classpath}"
+]`
+
+func LegacyGameArguments(minecraftArguments string) string {
+	b, _ := json.Marshal(strings.Fields(minecraftArguments))
+	return string(b)
+}
+func CollectArguments(arguments gjson.Result, ctx *RuleContext) ([]string, error) {
+	args := []string{}
+	for _, v := range arguments.Array() {
+		if v.Type == gjson.String {
+			args = append(args, v.String())
+			continue
+		}
+		if !ctx.Allows(v.Get("rules")) {
+			continue
+		}
+		value := v.Get("value")
+		if value.Type == gjson.String {
+			args = append(args, value.String())
+			continue
+		}
+		if !value.IsArray() {
+			return nil, NewNotArray()
+		}
+		for _, vs := range value.Array() {
+			args = append(args, vs.String())
+		}
+	}
+	return args, nil
+}
+func HasFeatureRule(arguments gjson.Result, feature string) bool {
+	found := false
+	arguments.ForEach(func(_, value gjson.Result) bool {
+		value.Get("rules").ForEach(func(_, rule gjson.Result) bool {
+			if rule.Get("features").Get(feature).Exists() {
+				found = true
+			}
+			return !found
+		})
+		return !found
+	})
+	return found
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestRuleContextAllows(t *testing.T) {
+	ctx := &RuleContext{OsName: "linux", OsArch: "x86_64", OsVersion: "6.1.0", Features: map[string]bool{"has_custom_resolution": true}}
+	tests := []struct {
+		rules string
+		want  bool
+	}{
+		{``, true},
+		{`[]`, false},
+		{`[{"action":"allow"}]`, true},
+		{`[{"action":"allow","os":{"name":"osx"}}]`, false},
+		{`[{"action":"allow","os":{"name":"linux"}}]`, true},
+		{`[{"action":"allow"},{"action":"disallow","os":{"name":"linux"}}]`, false},
+		{`[{"action":"allow"},{"action":"disallow","os":{"name":"osx"}}]`, true},
+		{`[{"action":"allow","os":{"arch":"x86"}}]`, false},
+		{`[{"action":"allow","os":{"name":"linux","version":"^6\\."}}]`, true},
+		{`[{"action":"allow","os":{"name":"linux","version":"^5\\."}}]`, false},
+		{`[{"action":"allow","features":{"has_custom_resolution":true}}]`, true},
+		{`[{"action":"allow","features":{"is_demo_user":true}}]`, false},
+	}
+	for _, tt := range tests {
+		if got := ctx.Allows(gjson.Parse(tt.rules)); got != tt.want {
+			t.Errorf("Allows(%s) = %t, want %t", tt.rules, got, tt.want)
+		}
+	}
+}
@@ -3,11 +3,11 @@ package main
 import (
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
-	"runtime"
+	"strconv"
 	"strings"
-	"time"
 
 	"github.com/tidwall/gjson"
 )
@@ -33,11 +33,47 @@ func (source Source) String() string {
 	}
 }
 
+func (source Source) Rewrite(url string) string {
+	var root string
+	switch source {
+	case Mcbbs:
+		root = "https://download.mcbbs.net"
+	case BMCLAPI:
+		root = "https://bmclapi2.bangbang93.com"
+	default:
+		return url
+	}
+	return ReplaceByMap(url, map[string]string{
+		"http://launchermeta.mojang.com":           root,
+		"https://launchermeta.mojang.com":          root,
+		"https://piston-meta.mojang.com":           root,
+		"https://piston-data.mojang.com":           root,
+		"https://launcher.mojang.com":              root,
+		"https://libraries.minecraft.net":          root + "/maven",
+		"https://resources.download.minecraft.net": root + "/assets",
+		"https://authlib-injector.yushi.moe":       root + "/mirrors/authlib-injector",
+		"https://maven.fabricmc.net":               root + "/maven",
+		"https://meta.fabricmc.net":                root + "/fabric-meta",
+		"https://maven.minecraftforge.net":         root + "/maven",
+		"https://maven.neoforged.net/releases":     root + "/maven",
+	})
+}
+func DefaultSources(primary Source) []Source {
+	sources := []Source{primary}
+	for _, source := range []Source{BMCLAPI, Mojang, Mcbbs} {
+		if source != primary {
+			sources = append(sources, source)
+		}
+	}
+	return sources
+}
+
 type User int
 
 const (
 	MojangLogin User = iota
 	MicrosoftLogin
+	LegacyLogin
 )
 
 func (u User) String() string {
@@ -46,6 +82,8 @@ func (u User) String() string {
 		return "mojang"
 	case MicrosoftLogin:
 		return "Microsoft"
+	case LegacyLogin:
+		return "legacy"
 	default:
 		return "mojang"
 	}
@@ -61,6 +99,20 @@ type McDownloader struct {
 	versionJson   gjson.Result
 	Cp            string
 	PassWord      string
+	Concurrency   int
+	Sources       []Source
+	Incremental   bool
+	Microsoft     *MicrosoftAuth
+	YggdrasilUrl  string
+	Accounts      *AccountStore
+	Config        *Config
+	Options       *LaunchOptions
+	Warnings      []error
+	GameStdout    io.Writer
+	GameStderr    io.Writer
+	classpath     *Classpath
+	javaRuntimes  []*JavaRuntime
+	health        *SourceHealth
 }
 type NotArray struct{}
 
@@ -91,6 +143,17 @@ func NewVersionNotFound(errid string) *VersionNotFound {
 	return &VersionNotFound{errId: errid}
 }
 
+type InheritLoop struct {
+	Id string
+}
+
+func (i *InheritLoop) Error() string {
+	return "version \"" + i.Id + "\" inherits from itself"
+}
+func NewInheritLoop(id string) *InheritLoop {
+	return &InheritLoop{Id: id}
+}
+
 type HashNotSame struct {
 	Need string
 	Got  string
@@ -102,370 +165,529 @@ func (h *HashNotSame) Error() string {
 func NewHashNotSame(need, got string) *HashNotSame {
 	return &HashNotSame{Need: need, Got: got}
 }
+
+type BadStatus struct {
+	Url  string
+	Code int
+}
+
+func (b *BadStatus) Error() string {
+	return fmt.Sprintf("got status %d from %s", b.Code, b.Url)
+}
+func NewBadStatus(url string, code int) *BadStatus {
+	return &BadStatus{Url: url, Code: code}
+}
+
+type NativeNotFound struct {
+	Library    string
+	Classifier string
+}
+
+func (n *NativeNotFound) Error() string {
+	return "library \"" + n.Library + "\" has no classifier \"" + n.Classifier + "\""
+}
+func NewNativeNotFound(library, classifier string) *NativeNotFound {
+	return &NativeNotFound{Library: library, Classifier: classifier}
+}
+
+type JavaNotFound struct {
+	MajorVersion int64
+}
+
+func (j *JavaNotFound) Error() string {
+	return fmt.Sprintf("has not java version %d", j.MajorVersion)
+}
+func NewJavaNotFound(majorVersion int64) *JavaNotFound {
+	return &JavaNotFound{MajorVersion: majorVersion}
+}
+
+type MicrosoftAuthError struct {
+	Code        string
+	Description string
+}
+
+func (m *MicrosoftAuthError) Error() string {
+	return "microsoft auth: " + m.Code + ": " + m.Description
+}
+func NewMicrosoftAuthError(code, description string) *MicrosoftAuthError {
+	return &MicrosoftAuthError{Code: code, Description: description}
+}
+
+type XboxError struct {
+	XErr    int64
+	Message string
+}
+
+func (x *XboxError) Error() string {
+	switch x.XErr {
+	case 2148916233:
+		return "this microsoft account has no xbox account"
+	case 2148916235:
+		return "xbox live is not available in this country"
+	case 2148916236, 2148916237:
+		return "this microsoft account needs adult verification"
+	case 2148916238:
+		return "this microsoft account is a child account and must be added to a family"
+	}
+	return fmt.Sprintf("xbox error %d: %s", x.XErr, x.Message)
+}
+func NewXboxError(xerr int64, message string) *XboxError {
+	return &XboxError{XErr: xerr, Message: message}
+}
+
+type YggdrasilError struct {
+	ErrorType string
+	Message   string
+}
+
+func (y *YggdrasilError) Error() string {
+	return y.ErrorType + ": " + y.Message
+}
+func NewYggdrasilError(errorType, message string) *YggdrasilError {
+	return &YggdrasilError{ErrorType: errorType, Message: message}
+}
+
+type ProfileNotSelected struct {
+	Account  string
+	Profiles []YggdrasilProfile
+}
+
+func (p *ProfileNotSelected) Error() string {
+	s := "account " + p.Account + " has no selected profile, choose one of:"
+	for _, v := range p.Profiles {
+		s += "\n" + v.Name + " (" + v.Id + ")"
+	}
+	return s
+}
+func NewProfileNotSelected(account string, profiles []YggdrasilProfile) *ProfileNotSelected {
+	return &ProfileNotSelected{Account: account, Profiles: profiles}
+}
+
+type BadRuntimePath struct {
+	Path string
+}
+
+func (b *BadRuntimePath) Error() string {
+	return "illegal path in java runtime manifest: " + b.Path
+}
+func NewBadRuntimePath(path string) *BadRuntimePath {
+	return &BadRuntimePath{Path: path}
+}
+
+type UnresolvedPlaceholder struct {
+	Name     string
+	Argument string
+}
+
+func (u *UnresolvedPlaceholder) Error() string {
+	return "unresolved placeholder ${" + u.Name + "} in argument " + u.Argument
+}
+func NewUnresolvedPlaceholder(name string, argument string) *UnresolvedPlaceholder {
+	return &UnresolvedPlaceholder{Name: name, Argument: argument}
+}
+
+type QuickPlayUnsupported struct {
+	Mode string
+}
+
+func (q *QuickPlayUnsupported) Error() string {
+	return "quick play " + q.Mode + " needs a version with quickPlay support, ignored"
+}
+func NewQuickPlayUnsupported(mode string) *QuickPlayUnsupported {
+	return &QuickPlayUnsupported{Mode: mode}
+}
+
+type ConfigError struct {
+	Key     string
+	Message string
+}
+
+func (c *ConfigError) Error() string {
+	if c.Key == "" {
+		return "config: " + c.Message
+	}
+	return "config: " + c.Key + ": " + c.Message
+}
+func NewConfigError(key, message string) *ConfigError {
+	return &ConfigError{Key: key, Message: message}
+}
+
+type DownloadErrors struct {
+	Errs []error
+}
+
+func (d *DownloadErrors) Error() string {
+	s := fmt.Sprintf("%d downloads failed", len(d.Errs))
+	for _, err := range d.Errs {
+		s += "\n" + err.Error()
+	}
+	return s
+}
+func NewDownloadErrors(errs []error) *DownloadErrors {
+	return &DownloadErrors{Errs: errs}
+}
+
+type AllSourcesFailed struct {
+	Url  string
+	Errs []error
+}
+
+func (a *AllSourcesFailed) Error() string {
+	s := "all sources failed for " + a.Url
+	for _, err := range a.Errs {
+		s += "\n" + err.Error()
+	}
+	return s
+}
+func NewAllSourcesFailed(url string, errs []error) *AllSourcesFailed {
+	return &AllSourcesFailed{Url: url, Errs: errs}
+}
 func NewMcDownloader(sourceType, username, userloginType, userType, mcDir, password string, needVer string) (*McDownloader, error) {
-	mcDir, err := filepath.Abs(mcDir)
+	config, err := LoadDefaultConfig()
+	if err != nil {
+		return nil, err
+	}
+	if sourceType == "" {
+		sourceType = config.Source
+	}
+	if mcDir == "" {
+		mcDir = config.GameDir
+	}
+	mcDir, err = filepath.Abs(mcDir)
 	if err != nil {
 		return nil, err
 	}
-	err = os.MkdirAll(mcDir, 0666)
+	err = os.MkdirAll(mcDir, 0755)
 	if err != nil {
 		return nil, err
 	}
 	versionDir := filepath.Join(mcDir, "versions")
-	err = os.MkdirAll(versionDir, 0666)
+	err = os.MkdirAll(versionDir, 0755)
 	if err != nil {
 		return nil, err
 	}
-	var versionInfoUrl string = "http://launchermeta.mojang.com/mc/game/version_manifest_v2.json"
 	var rSourceType Source = Mojang
 	switch sourceType {
 	case "mcbbs":
 		rSourceType = Mcbbs
-		versionInfoUrl = "https://download.mcbbs.net/mc/game/version_manifest_v2.json"
 	case "bmclapi":
 		rSourceType = BMCLAPI
-		versionInfoUrl = "https://bmclapi2.bangbang93.com/mc/game/version_manifest_v2.json"
-	}
-	verInfoByte, err := GetByteInInternet(versionInfoUrl)
-	if err != nil {
-		return nil, err
 	}
-	err = WriteFmtJsonBytes("version_manifest_v2.json", verInfoByte)
-	if err != nil {
-		return nil, err
-	}
-	verInfoString := string(verInfoByte)
-	verInfo := gjson.Get(verInfoString, "versions")
-	if !verInfo.IsArray() {
-		return nil, NewNotArray()
-	}
-	hasVer := false
-	var verInfos gjson.Result
-	for _, v := range verInfo.Array() {
-		if needVer == v.Get("id").String() {
-			hasVer = true
-			verInfos = v
-		}
-	}
-	if !hasVer {
-		return nil, NewVersionNotFound(needVer)
+	var ruserloginType User = MojangLogin
+	switch userloginType {
+	case "Microsoft":
+		ruserloginType = MicrosoftLogin
+	case "offline":
+		ruserloginType = LegacyLogin
 	}
-	verPath := filepath.Join(versionDir, needVer)
-	err = os.MkdirAll(verPath, 0666)
+	accounts, err := LoadAccountStore(config.AccountsFile(mcDir))
 	if err != nil {
 		return nil, err
 	}
-	verJsonPath := filepath.Join(verPath, needVer+".json")
-	versionUrl := verInfos.Get("url").String()
-	versionSha1 := verInfos.Get("sha1").String()
-	switch sourceType {
-	case "mcbbs":
-		versionUrl = ReplaceByMap(versionUrl, map[string]string{
-			"https://piston-meta.mojang.com":  "https://download.mcbbs.net",
-			"https://launchermeta.mojang.com": "https://download.mcbbs.net",
-			"https://launcher.mojang.com":     "https://download.mcbbs.net",
-		})
-	case "bmclapi":
-		versionUrl = ReplaceByMap(versionUrl, map[string]string{
-			"https://piston-meta.mojang.com":  "https://bmclapi2.bangbang93.com",
-			"https://launchermeta.mojang.com": "https://bmclapi2.bangbang93.com",
-			"https://launcher.mojang.com":     "https://bmclapi2.bangbang93.com",
-		})
-	}
-	versionString, err := GetStrFmtJsonInInternetWithHash(versionUrl, "sha1", versionSha1)
-	if err != nil {
-		return nil, err
+	if config.Accounts.Default != "" && accounts.Get(config.Accounts.Default) != nil {
+		accounts.Selected = config.Accounts.Default
 	}
-	err = DownloadFmtJsonWithHash(verJsonPath, versionUrl, "sha1", versionSha1)
+	downloader := &McDownloader{UserType: ruserloginType, SourceType: rSourceType, UserName: username, UserLoginType: userloginType, McDir: mcDir, PassWord: password, Concurrency: config.Concurrency, Sources: DefaultSources(rSourceType), Incremental: true, Accounts: accounts, Config: config, health: NewSourceHealth()}
+	err = downloader.LoadVersion(needVer)
 	if err != nil {
 		return nil, err
 	}
-	var ruserloginType User = MojangLogin
-	if userloginType == "Microsoft" {
-		ruserloginType = MicrosoftLogin
-	}
-	return &McDownloader{UserType: ruserloginType, SourceType: rSourceType, UserName: username, UserLoginType: userloginType, VersionJson: versionString, versionJson: gjson.Parse(versionString), McDir: mcDir, PassWord: password}, nil
+	return downloader, nil
 }
 func (self *McDownloader) GetLib() error {
 	if !self.versionJson.Get("libraries").IsArray() {
 		return NewNotArray()
 	}
-	var cp string
+	classpath := NewClasspath()
+	if self.classpath != nil {
+		classpath.Client = self.classpath.Client
+	}
 	libdir := filepath.Join(self.McDir, "libraries")
-	err := os.MkdirAll(libdir, 0666)
+	err := os.MkdirAll(libdir, 0755)
 	if err != nil {
 		return err
 	}
 	lib := self.versionJson.Get("libraries").Array()
+	scheduler := self.newScheduler()
+	ctx := NewRuleContext()
+	verid := self.versionJson.Get("id").String()
+	nativedir := filepath.Join(self.McDir, "versions", verid, "natives")
+	submitted := map[string]bool{}
 	for _, v := range lib {
-		rules := v.Get("rules")
-		if rules.Exists() {
-			var notsame bool = false
-			for _, vr := range rules.Array() {
-				action := vr.Get("action")
-				if action.Exists() {
-					if action.String() != "allow" {
-						continue
-					}
-				}
-				oses := vr.Get("os")
-				if oses.Exists() {
-					oses.ForEach(func(key, value gjson.Result) bool {
-						if key.String() == "name" && value.String() != "windows" {
-							notsame = true
-							return false
-						}
-						if key.String() == "arch" && value.String() != "x"+strings.TrimLeft(runtime.GOARCH, "amd") {
-							notsame = true
-							return false
-						}
-						return true
-					})
-				}
-			}
-			if notsame {
-				continue
-			}
-		}
-		downloads := v.Get("downloads")
-		if !downloads.Exists() {
+		if !ctx.Allows(v.Get("rules")) {
 			continue
 		}
-		artifact := downloads.Get("artifact")
-		if !artifact.Exists() {
-			continue
+		downloads := v.Get("downloads")
+		classifier := v.Get("natives").Get(ctx.OsName)
+		if classifier.Exists() && downloads.Exists() {
+			classifierName := strings.ReplaceAll(classifier.String(), "${arch}", strconv.Itoa(strconv.IntSize))
+			native := downloads.Get("classifiers").Get(classifierName)
+			if !native.Exists() {
+				return NewNativeNotFound(v.Get("name").String(), classifierName)
+			}
+			nativepath := filepath.Join(libdir, filepath.FromSlash(native.Get("path").String()))
+			nativeurl := native.Get("url").String()
+			nativesha1 := native.Get("sha1").String()
+			nativesize := native.Get("size").Int()
+			exclude := []string{}
+			for _, e := range v.Get("extract.exclude").Array() {
+				exclude = append(exclude, e.String())
+			}
+			if !submitted[nativepath] {
+				submitted[nativepath] = true
+				scheduler.Submit(func() error {
+					err := self.Fetch(nativepath, nativeurl, "sha1", nativesha1, nativesize)
+					if err != nil {
+						return err
+					}
+					err = UnzipWithExclude(nativepath, nativedir, exclude)
+					if err != nil {
+						return err
+					}
+					println(nativepath)
+					return nil
+				})
+			}
 		}
-		pathResult := artifact.Get("path")
-		if !pathResult.Exists() {
+		artifact := NewLibraryArtifact(v)
+		if artifact == nil || artifact.Path == "" {
 			continue
 		}
-		paths := pathResult.String()
-		paths = ReplaceByMap(paths, map[string]string{
-			"/": "\\",
-		})
-		libpath := filepath.Join(libdir, paths)
+		libpath := filepath.Join(libdir, filepath.FromSlash(artifact.Path))
 		libdirs, _ := filepath.Split(libpath)
-		err = os.MkdirAll(libdirs, 0666)
+		err = os.MkdirAll(libdirs, 0755)
 		if err != nil {
 			return err
 		}
-		liburlResult := artifact.Get("url")
-		if !liburlResult.Exists() {
+		if artifact.Url == "" {
+			classpath.Add(v.Get("name").String(), libpath)
 			continue
 		}
-		liburl := liburlResult.String()
-		switch self.SourceType {
-		case Mcbbs:
-			liburl = ReplaceByMap(liburl, map[string]string{
-				"https://libraries.minecraft.net": "https://download.mcbbs.net/maven",
-			})
-		case BMCLAPI:
-			liburl = ReplaceByMap(liburl, map[string]string{
-				"https://libraries.minecraft.net": "https://bmclapi2.bangbang93.com/maven",
-			})
-		}
-		libsha1Result := artifact.Get("sha1")
-		if !libsha1Result.Exists() {
+		classpath.Add(v.Get("name").String(), libpath)
+		if submitted[libpath] {
 			continue
 		}
-		libsha1 := libsha1Result.String()
-		err = DownloadWithHash(libpath, liburl, "sha1", libsha1)
-		if err != nil {
-			return err
-		}
-		cp += libpath + ";"
-		println(libpath)
-		time.Sleep(800)
+		submitted[libpath] = true
+		liburl := artifact.Url
+		libsha1 := artifact.Sha1
+		libsize := artifact.Size
+		scheduler.Submit(func() error {
+			var err error
+			if libsha1 == "" {
+				err = self.FetchMaven(libpath, liburl)
+			} else {
+				err = self.Fetch(libpath, liburl, "sha1", libsha1, libsize)
+			}
+			if err != nil {
+				return err
+			}
+			println(libpath)
+			return nil
+		})
+	}
+	err = scheduler.Wait()
+	if err != nil {
+		return err
 	}
-	self.Cp = cp
+	self.classpath = classpath
+	self.Cp = classpath.String()
 	return nil
 }
-func (self *McDownloader) Launch(startname string, isdomo bool) (string, error) {
-	switch self.UserLoginType {
-	case "littleskin":
-		tokens := RandStringBytes(len("ssssdddadfsdfsfsffsxxdsfewfsdf"))
-		value := map[string]interface{}{
-			"agent": map[string]interface{}{
-				"name":    "Minecraft",
-				"version": self.versionJson.Get("complianceLevel").Int(),
-			},
-			"username":    self.UserName,
-			"password":    self.PassWord,
-			"clientToken": tokens,
-			"requestUser": false,
-		}
-		gets, err := PostMapGotBytes("https://littleskin.cn/api/yggdrasil/authserver/authenticate", map[string]string{
-			"Content-Type": "application/json",
-		}, value)
-		if err != nil {
-			return "", err
-		}
-		getstr := string(gets)
-		AssertsTrue(gjson.Get(getstr, "clientToken").String() == tokens)
-		availableProfiles := gjson.Get(getstr, "availableProfiles")
-		if !availableProfiles.Exists() || !availableProfiles.IsArray() {
-			return "", NewNotArray()
-		}
-		var uuid string = ""
-		var hasName bool = false
-		for _, v := range availableProfiles.Array() {
-			if v.Get("name").String() == startname {
-				hasName = true
-				uuid = v.Get("id").String()
-			}
-		}
-		if !hasName {
-			return "", errors.New("no name you have")
-		}
-		accessToken := gjson.Get(getstr, "accessToken").String()
-		assetsDir := filepath.Join(self.McDir, "assets")
-		indexDir := filepath.Join(assetsDir, "indexes")
-		err = os.MkdirAll(assetsDir, 0666)
-		if err != nil {
-			return "", err
-		}
-		err = os.MkdirAll(indexDir, 0666)
-		if err != nil {
-			return "", err
-		}
-		assetsIndexResult := self.versionJson.Get("assetIndex")
-		if !assetsIndexResult.Exists() {
-			return "", err
-		}
-		assetsIndexName := assetsIndexResult.Get("id").String()
-		indexJsonPath := filepath.Join(indexDir, assetsIndexName+".json")
-		indexJson, err := GetStrFmtJsonInInternetWithHash(assetsIndexResult.Get("url").String(), "sha1", assetsIndexResult.Get("sha1").String())
-		if err != nil {
-			return "", err
-		}
-		err = WriteBytes(indexJsonPath, []byte(indexJson))
-		if err != nil {
-			return "", err
-		}
-		self.Cp = "\"" + self.Cp + "\""
-		fmt.Printf("isdomo:%t\n", isdomo)
-		vername := self.versionJson.Get("id").String()
-		versiondir := filepath.Join(self.McDir, "versions", vername)
-		nativedir := filepath.Join(versiondir, "natives")
-		err = os.MkdirAll(nativedir, 0666)
-		if err != nil {
-			return "", err
-		}
-		version_type := self.versionJson.Get("type").String()
-		mainclassname := self.versionJson.Get("mainClass").String()
-		args := self.versionJson.Get("arguments")
-		gameargs := []string{}
-		gamearg := args.Get("game")
-		jvmargs := []string{}
-		jvmarg := args.Get("jvm")
-		err = self.GetObj(indexJson)
+func (self *McDownloader) Launch(startname string, isdomo bool) (*GameProcess, error) {
+	java, args, err := self.LaunchArgs(startname, isdomo)
+	if err != nil {
+		return nil, err
+	}
+	println(java + " " + strings.Join(RedactArgs(args), " "))
+	stdout := self.GameStdout
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+	stderr := self.GameStderr
+	if stderr == nil {
+		stderr = os.Stderr
+	}
+	return StartGameProcess(java, args, self.McDir, stdout, stderr)
+}
+func (self *McDownloader) LaunchArgs(startname string, isdomo bool) (string, []string, error) {
+	self.Warnings = nil
+	auth, err := self.Login(startname)
+	if err != nil {
+		return "", nil, err
+	}
+	assetsDir := filepath.Join(self.McDir, "assets")
+	indexDir := filepath.Join(assetsDir, "indexes")
+	err = os.MkdirAll(assetsDir, 0755)
+	if err != nil {
+		return "", nil, err
+	}
+	err = os.MkdirAll(indexDir, 0755)
+	if err != nil {
+		return "", nil, err
+	}
+	assetsIndexResult := self.versionJson.Get("assetIndex")
+	if !assetsIndexResult.Exists() {
+		return "", nil, errors.New("version " + self.versionJson.Get("id").String() + " has no assetIndex")
+	}
+	assetsIndexName := assetsIndexResult.Get("id").String()
+	indexJsonPath := filepath.Join(indexDir, assetsIndexName+".json")
+	indexSha1 := assetsIndexResult.Get("sha1").String()
+	if !IsFileSameHash(indexJsonPath, "sha1", indexSha1, assetsIndexResult.Get("size").Int()) {
+		err = self.DownloadWithFailover(indexJsonPath, assetsIndexResult.Get("url").String(), "sha1", indexSha1)
 		if err != nil {
-			return "", err
-		}
-		for _, v := range gamearg.Array() {
-			if v.Type.String() == "String" {
-				gameargs = append(gameargs, v.String())
-				continue
-			}
-			rules := v.Get("rules")
-			var notsame bool = IsRuleSameFrom_gjson_Result(rules, isdomo)
-			if notsame {
-				continue
-			}
-			value := v.Get("value")
-			if value.Type.String() == "String" {
-				gameargs = append(gameargs, value.String())
-				continue
-			}
-			if !value.IsArray() {
-				return "", errors.New("not array")
-			}
-			for _, vs := range value.Array() {
-				gameargs = append(gameargs, vs.String())
-			}
-		}
-		gamestrargs := ""
-		for _, v := range gameargs {
-			gamestrargs += v + " "
-		}
-		gamestrargs = strings.TrimRight(gamestrargs, " ")
-		gamestrargs = ReplaceByMap(gamestrargs, map[string]string{
-			"${auth_player_name}":  startname,
-			"${version_name}":      vername,
-			"${game_directory}":    "\"" + self.McDir + "\"",
-			"${assets_root}":       "\"" + assetsDir + "\"",
-			"${assets_index_name}": assetsIndexName,
-			"${auth_uuid}":         uuid,
-			"${auth_access_token}": accessToken,
-			"${user_type}":         self.UserType.String(),
-			"${version_type}":      version_type,
-			"${resolution_width}":  "854",
-			"${resolution_height}": "480",
-		})
-		clientid := "112321"
-		if clientid != "" {
-			gamestrargs = strings.ReplaceAll(gamestrargs, "${clientid}", clientid)
-		}
-		auth_xuid := "114514"
-		if auth_xuid != "" {
-			gamestrargs = strings.ReplaceAll(gamestrargs, "${auth_xuid}", auth_xuid)
+			return "", nil, err
 		}
-		for _, v := range jvmarg.Array() {
-			if v.Type.String() == "String" {
-				jvmargs = append(jvmargs, v.String())
-				continue
-			}
-			rules := v.Get("rules")
-			var notsame bool = IsRuleSameFrom_gjson_Result(rules, isdomo)
-			if notsame {
-				continue
-			}
-			value := v.Get("value")
-			if value.Type.String() == "String" {
-				jvmargs = append(jvmargs, value.String())
-				continue
-			}
-			if !value.IsArray() {
-				return "", errors.New("not array")
-			}
-			for _, vs := range value.Array() {
-				jvmargs = append(jvmargs, vs.String())
-			}
-		}
-		jvmStrargs := ""
-		for _, v := range jvmargs {
-			jvmStrargs += v + " "
+	}
+	indexJsonByte, err := os.ReadFile(indexJsonPath)
+	if err != nil {
+		return "", nil, err
+	}
+	indexJson := string(indexJsonByte)
+	fmt.Printf("isdomo:%t\n", isdomo)
+	ctx := NewRuleContext()
+	ctx.Features["is_demo_user"] = isdomo
+	options, err := self.LaunchOptions()
+	if err != nil {
+		return "", nil, err
+	}
+	ctx.Features["has_custom_resolution"] = options.CustomResolution()
+	options.QuickPlay(ctx)
+	vername := self.versionJson.Get("id").String()
+	versiondir := filepath.Join(self.McDir, "versions", vername)
+	nativedir := filepath.Join(versiondir, "natives")
+	err = os.MkdirAll(nativedir, 0755)
+	if err != nil {
+		return "", nil, err
+	}
+	version_type := self.versionJson.Get("type").String()
+	mainclassname := self.versionJson.Get("mainClass").String()
+	args := self.versionJson.Get("arguments")
+	gamearg := args.Get("game")
+	jvmarg := args.Get("jvm")
+	legacyargs := self.versionJson.Get("minecraftArguments")
+	if !args.Exists() && legacyargs.Exists() {
+		gamearg = gjson.Parse(LegacyGameArguments(legacyargs.String()))
+		jvmarg = gjson.Parse(LegacyJvmArguments)
+	}
+	err = self.GetObj(indexJson)
+	if err != nil {
+		return "", nil, err
+	}
+	gameargs, err := CollectArguments(gamearg, ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	if !args.Exists() && options.CustomResolution() {
+		gameargs = append(gameargs, "--width", "${resolution_width}", "--height", "${resolution_height}")
+	}
+	if options.Fullscreen {
+		gameargs = append(gameargs, "--fullscreen")
+	}
+	if !HasFeatureRule(gamearg, "is_quick_play_multiplayer") {
+		quickplayargs, warning := options.LegacyQuickPlayArgs()
+		if warning != nil {
+			self.warn(warning)
 		}
-		jvmStrargs = ReplaceByMap(jvmStrargs, map[string]string{
-			"${natives_directory}": "\"" + nativedir + "\"",
-			"${launcher_name}":     "newL",
-			"${launcher_version}":  "27",
-			"${classpath}":         self.Cp,
-			"-Dos.name=Windows 10": "-Dos.name=\"Windows 10\"",
-		})
-		allarg := jvmStrargs + " " + mainclassname + " " + gamestrargs
-		javaVersion := fmt.Sprintf("%d", self.versionJson.Get("javaVersion").Get("majorVersion").Int())
-		configs, err := ReadString("config.json")
+		gameargs = append(gameargs, quickplayargs...)
+	}
+	gameassets := assetsDir
+	if gjson.Get(indexJson, "virtual").Bool() || gjson.Get(indexJson, "map_to_resources").Bool() {
+		gameassets = filepath.Join(assetsDir, "virtual", "legacy")
+	}
+	jvmargs, err := CollectArguments(jvmarg, ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	if auth.Server != "" {
+		injectorargs, err := self.AuthlibInjectorArgs(auth.Server)
 		if err != nil {
-			return "", err
+			return "", nil, err
 		}
-		configjson := gjson.Parse(configs)
-		hasJavaVersion := configjson.Get("javaversions")
-		need := hasJavaVersion.Get(javaVersion)
-		if !need.Exists() {
-			println("Has not java version needs.")
-			return "", nil
+		jvmargs = append(jvmargs, injectorargs...)
+	}
+	jvmargs = append(jvmargs, options.MemoryArgs()...)
+	jvmargs = append(jvmargs, options.JvmArgs...)
+	gameargs = append(gameargs, options.GameArgs...)
+	vars := (&LaunchVariables{
+		AuthPlayerName:        auth.Name,
+		AuthUuid:              auth.Uuid,
+		AuthAccessToken:       auth.AccessToken,
+		AuthXuid:              auth.Xuid,
+		ClientId:              auth.ClientId,
+		UserType:              auth.UserType,
+		UserProperties:        "{}",
+		VersionName:           vername,
+		VersionType:           version_type,
+		GameDirectory:         self.McDir,
+		AssetsRoot:            assetsDir,
+		GameAssets:            gameassets,
+		AssetsIndexName:       assetsIndexName,
+		ResolutionWidth:       options.Width,
+		ResolutionHeight:      options.Height,
+		QuickPlayPath:         options.QuickPlayPath,
+		QuickPlaySingleplayer: options.QuickPlaySingleplayer,
+		QuickPlayMultiplayer:  options.QuickPlayMultiplayer,
+		QuickPlayRealms:       options.QuickPlayRealms,
+		NativesDirectory:      nativedir,
+		LibraryDirectory:      filepath.Join(self.McDir, "libraries"),
+		LauncherName:          "newL",
+		LauncherVersion:       "27",
+		Classpath:             self.Cp,
+		ClasspathSeparator:    string(filepath.ListSeparator),
+	}).Map()
+	jvmargs, jvmwarnings := SubstituteArguments(jvmargs, vars)
+	gameargs, gamewarnings := SubstituteArguments(gameargs, vars)
+	placeholderwarnings := append(jvmwarnings, gamewarnings...)
+	if options.StrictPlaceholders && len(placeholderwarnings) > 0 {
+		return "", nil, placeholderwarnings[0]
+	}
+	self.warn(placeholderwarnings...)
+	allargs := append(append(jvmargs, mainclassname), gameargs...)
+	java, err := self.FindJava(self.versionJson.Get("javaVersion").Get("majorVersion").Int())
+	if err != nil {
+		return "", nil, err
+	}
+	return java, allargs, nil
+}
+func (self *McDownloader) warn(warnings ...error) {
+	for _, w := range warnings {
+		println("warning: " + w.Error())
+		self.Warnings = append(self.Warnings, w)
+	}
+}
+func (self *McDownloader) FindJava(majorVersion int64) (string, error) {
+	if majorVersion <= 0 {
+		majorVersion = 8
+	}
+	if self.Config != nil {
+		need, ok := self.Config.JavaPaths[fmt.Sprintf("%d", majorVersion)]
+		if ok {
+			return need, nil
 		}
-		needPath := "\"" + need.String() + "\""
-		command := "@echo off\n" + needPath + " " + allarg
-		println(command)
-		return command, nil
 	}
-	return "", nil
+	java := SelectJavaRuntime(self.JavaRuntimes(), majorVersion)
+	if java != nil && java.Major == majorVersion {
+		return java.Path, nil
+	}
+	component := self.versionJson.Get("javaVersion.component").String()
+	if component == "" {
+		component = "jre-legacy"
+	}
+	installed := self.InstalledJavaRuntime(component)
+	if installed != "" {
+		return installed, nil
+	}
+	println("downloading java runtime: " + component)
+	path, err := self.InstallJavaRuntime(component)
+	if err != nil && java != nil {
+		println("java runtime " + component + " unavailable, falling back to java " + java.Version + ": " + err.Error())
+		return java.Path, nil
+	}
+	return path, err
 }
 func (self *McDownloader) GetObj(assetIndex string) error {
 	assetsDir := filepath.Join(self.McDir, "assets")
-	needBackup := false
+	needBackup := gjson.Get(assetIndex, "virtual").Bool()
 	if gjson.Get(assetIndex, "map_to_resources").Exists() {
 		if gjson.Get(assetIndex, "map_to_resources").IsBool() {
 			if gjson.Get(assetIndex, "map_to_resources").Bool() {
@@ -474,94 +696,83 @@ func (self *McDownloader) GetObj(assetIndex string) error {
 		}
 	}
 	objdir := filepath.Join(assetsDir, "objects")
-	err := os.RemoveAll(objdir)
-	if err != nil {
-		return err
+	if !self.Incremental {
+		err := os.RemoveAll(objdir)
+		if err != nil {
+			return err
+		}
 	}
 	objs := gjson.Get(assetIndex, "objects")
 	if !objs.IsObject() {
 		return NewNotObj()
 	}
-	var theErr error = nil
+	hashes := []string{}
+	names := map[string][]string{}
+	sizes := map[string]int64{}
 	objs.ForEach(func(key, value gjson.Result) bool {
-		var rootUrl string = "https://resources.download.minecraft.net/"
-		switch self.SourceType {
-		case Mcbbs:
-			rootUrl = "https://download.mcbbs.net/assets/"
-		case BMCLAPI:
-			rootUrl = "https://bmclapi2.bangbang93.com/assets/"
-		}
 		hashcode := value.Get("hash").String()
+		if _, ok := names[hashcode]; !ok {
+			hashes = append(hashes, hashcode)
+			sizes[hashcode] = value.Get("size").Int()
+		}
+		names[hashcode] = append(names[hashcode], key.String())
+		return true
+	})
+	scheduler := self.newScheduler()
+	for _, hashcode := range hashes {
+		hashcode := hashcode
 		twoHash := hashcode[:2]
-		url := rootUrl + twoHash + "/" + hashcode
-
+		url := "https://resources.download.minecraft.net/" + twoHash + "/" + hashcode
 		path := filepath.Join(objdir, twoHash, hashcode)
-		pathdir := filepath.Dir(path)
-		err = os.MkdirAll(pathdir, 0666)
-		if err != nil {
-			theErr = err
-			return false
-		}
-		objb, err := GetByteInInternet(url)
-		if err != nil {
-			theErr = err
-			return false
-		}
-		err = WriteBytes(path, objb)
-		if err != nil {
-			theErr = err
-			return false
-		}
-		if needBackup {
-			backuppath := filepath.Join(assetsDir, "virtual", "legacy", ReplaceByMap(key.String(), map[string]string{
-				"/": "\\",
-			}))
-			err = os.MkdirAll(filepath.Dir(backuppath), 0666)
+		size := sizes[hashcode]
+		objnames := names[hashcode]
+		scheduler.Submit(func() error {
+			err := self.Fetch(path, url, "sha1", hashcode, size)
 			if err != nil {
-				theErr = err
-				return false
+				return err
 			}
-			err = WriteBytes(backuppath, objb)
-			if err != nil {
-				theErr = err
-				return false
+			if needBackup {
+				for _, name := range objnames {
+					backuppath := filepath.Join(assetsDir, "virtual", "legacy", filepath.FromSlash(name))
+					if self.Incremental && IsFileSameHash(backuppath, "sha1", hashcode, size) {
+						continue
+					}
+					err = CopyFile(backuppath, path)
+					if err != nil {
+						return err
+					}
+				}
 			}
-		}
-		println(hashcode)
-		time.Sleep(800)
-		return true
-	})
-	if theErr != nil {
-		return theErr
+			println(hashcode)
+			return nil
+		})
 	}
-	return nil
+	return scheduler.Wait()
 }
 func (self *McDownloader) GetClient() error {
 	println("downloading: client")
 	client := self.versionJson.Get("downloads").Get("client")
 	url := client.Get("url").String()
 	clientSha1 := client.Get("sha1").String()
+	clientSize := client.Get("size").Int()
 	verid := self.versionJson.Get("id").String()
-	verjar := filepath.Join(self.McDir, "versions", verid, verid+".jar")
-	switch self.SourceType {
-	case Mcbbs:
-		url = ReplaceByMap(url, map[string]string{
-			"https://piston-data.mojang.com":  "https://download.mcbbs.net",
-			"https://launchermeta.mojang.com": "https://download.mcbbs.net",
-			"https://launcher.mojang.com":     "https://download.mcbbs.net",
-		})
-	case BMCLAPI:
-		url = ReplaceByMap(url, map[string]string{
-			"https://piston-data.mojang.com":  "https://bmclapi2.bangbang93.com",
-			"https://launchermeta.mojang.com": "https://bmclapi2.bangbang93.com",
-			"https://launcher.mojang.com":     "https://bmclapi2.bangbang93.com",
-		})
+	if jar := self.versionJson.Get("jar"); jar.Exists() {
+		verid = jar.String()
 	}
-	err := DownloadWithHash(verjar, url, "sha1", clientSha1)
+	verjar := filepath.Join(self.McDir, "versions", verid, verid+".jar")
+	scheduler := self.newScheduler()
+	scheduler.Submit(func() error {
+		return self.Fetch(verjar, url, "sha1", clientSha1, clientSize)
+	})
+	err := scheduler.Wait()
 	if err != nil {
 		return err
 	}
-	self.Cp += verjar
+	if self.classpath == nil {
+		self.classpath = NewClasspath()
+	}
+	self.classpath.Client = verjar
+	self.Cp = self.classpath.String()
 	println("downloaded: client")
 	return nil
 }
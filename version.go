@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/tidwall/gjson"
+)
+
+func VersionJsonPath(mcDir string, id string) string {
+	return filepath.Join(mcDir, "versions", id, id+".json")
+}
+func ReadVersionJson(mcDir string, id string) (map[string]interface{}, error) {
+	b, err := os.ReadFile(VersionJsonPath(mcDir, id))
+	if err != nil {
+		return nil, err
+	}
+	version := map[string]interface{}{}
+	err = json.Unmarshal(b, &version)
+	if err != nil {
+		return nil, err
+	}
+	return version, nil
+}
+func ResolveVersionJson(mcDir string, id string, install func(id string) error) (map[string]interface{}, error) {
+	chain := []map[string]interface{}{}
+	seen := map[string]bool{}
+	for id != "" {
+		if seen[id] {
+			return nil, NewInheritLoop(id)
+		}
+		seen[id] = true
+		if !FileNameIsExist(VersionJsonPath(mcDir, id)) {
+			err := install(id)
+			if err != nil {
+				return nil, err
+			}
+		}
+		version, err := ReadVersionJson(mcDir, id)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, version)
+		id, _ = version["inheritsFrom"].(string)
+	}
+	merged := chain[len(chain)-1]
+	for i := len(chain) - 2; i >= 0; i-- {
+		merged = MergeVersionJson(merged, chain[i])
+	}
+	return merged, nil
+}
+func MergeVersionJson(parent map[string]interface{}, child map[string]interface{}) map[string]interface{} {
+	merged := map[string]interface{}{}
+	for k, v := range parent {
+		merged[k] = v
+	}
+	for k, v := range child {
+		switch k {
+		case "libraries":
+			libraries, _ := v.([]interface{})
+			parentLibraries, _ := parent["libraries"].([]interface{})
+			merged[k] = append(append([]interface{}{}, libraries...), parentLibraries...)
+		case "arguments":
+			arguments, _ := v.(map[string]interface{})
+			parentArguments, _ := parent["arguments"].(map[string]interface{})
+			mergedArguments := map[string]interface{}{}
+			for name, list := range parentArguments {
+				mergedArguments[name] = list
+			}
+			for name, list := range arguments {
+				values, _ := list.([]interface{})
+				parentValues, _ := parentArguments[name].([]interface{})
+				mergedArguments[name] = append(append([]interface{}{}, parentValues...), values...)
+			}
+			merged[k] = mergedArguments
+		default:
+			merged[k] = v
+		}
+	}
+	if _, ok := child["jar"]; !ok {
+		if jar, ok := parent["jar"]; ok {
+			merged["jar"] = jar
+		} else {
+			merged["jar"] = parent["id"]
+		}
+	}
+	delete(merged, "inheritsFrom")
+	return merged
+}
+func (self *McDownloader) InstallVersionJson(id string) error {
+	verInfoByte, err := self.GetByteWithFailover("http://launchermeta.mojang.com/mc/game/version_manifest_v2.json")
+	if err != nil {
+		return err
+	}
+	err = WriteFmtJsonBytes("version_manifest_v2.json", verInfoByte)
+	if err != nil {
+		return err
+	}
+	verInfo := gjson.GetBytes(verInfoByte, "versions")
+	if !verInfo.IsArray() {
+		return NewNotArray()
+	}
+	hasVer := false
+	var verInfos gjson.Result
+	for _, v := range verInfo.Array() {
+		if id == v.Get("id").String() {
+			hasVer = true
+			verInfos = v
+		}
+	}
+	if !hasVer {
+		return NewVersionNotFound(id)
+	}
+	verJsonPath := VersionJsonPath(self.McDir, id)
+	err = os.MkdirAll(filepath.Dir(verJsonPath), 0755)
+	if err != nil {
+		return err
+	}
+	versionByte, err := self.GetByteWithFailoverAndHash(verInfos.Get("url").String(), "sha1", verInfos.Get("sha1").String())
+	if err != nil {
+		return err
+	}
+	return WriteFmtJsonBytes(verJsonPath, versionByte)
+}
+func (self *McDownloader) LoadVersion(id string) error {
+	merged, err := ResolveVersionJson(self.McDir, id, self.InstallVersionJson)
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(merged, "", "    ")
+	if err != nil {
+		return err
+	}
+	self.VersionJson = string(b)
+	self.versionJson = gjson.Parse(self.VersionJson)
+	self.classpath = nil
+	self.Cp = ""
+	return nil
+}
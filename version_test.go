@@ -0,0 +1,48 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeVersionJson(t *testing.T) {
+	parent := map[string]interface{}{
+		"id":        "1.20.1",
+		"mainClass": "net.minecraft.client.main.Main",
+		"type":      "release",
+		"libraries": []interface{}{"parent-lib"},
+		"arguments": map[string]interface{}{
+			"game": []interface{}{"--username"},
+			"jvm":  []interface{}{"-cp"},
+		},
+	}
+	child := map[string]interface{}{
+		"id":           "1.20.1-fabric",
+		"inheritsFrom": "1.20.1",
+		"mainClass":    "net.fabricmc.loader.impl.launch.knot.KnotClient",
+		"libraries":    []interface{}{"child-lib"},
+		"arguments": map[string]interface{}{
+			"jvm": []interface{}{"-DFabricMcEmu"},
+		},
+	}
+	merged := MergeVersionJson(parent, child)
+	want := map[string]interface{}{
+		"id":        "1.20.1-fabric",
+		"mainClass": "net.fabricmc.loader.impl.launch.knot.KnotClient",
+		"type":      "release",
+		"jar":       "1.20.1",
+		"libraries": []interface{}{"child-lib", "parent-lib"},
+		"arguments": map[string]interface{}{
+			"game": []interface{}{"--username"},
+			"jvm":  []interface{}{"-cp", "-DFabricMcEmu"},
+		},
+	}
+	if !reflect.DeepEqual(merged, want) {
+		t.Fatalf("MergeVersionJson() = %v, want %v", merged, want)
+	}
+
+	child["jar"] = "custom"
+	if merged := MergeVersionJson(parent, child); merged["jar"] != "custom" {
+		t.Fatalf("jar = %v, want custom", merged["jar"])
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+const LittleskinServer = "https://littleskin.cn/api/yggdrasil"
+
+const AuthlibInjectorCheckInterval = 24 * time.Hour
+
+type YggdrasilProfile struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type YggdrasilSession struct {
+	AccessToken       string
+	ClientToken       string
+	SelectedProfile   *YggdrasilProfile
+	AvailableProfiles []YggdrasilProfile
+}
+
+type YggdrasilClient struct {
+	ServerUrl string
+}
+
+func NewYggdrasilClient(serverUrl string) *YggdrasilClient {
+	return &YggdrasilClient{ServerUrl: strings.TrimRight(serverUrl, "/")}
+}
+func ResolveYggdrasilServer(serverUrl string) (string, error) {
+	r, err := HttpGet(serverUrl)
+	if err != nil {
+		return "", err
+	}
+	defer r.Body.Close()
+	location := r.Header.Get("X-Authlib-Injector-API-Location")
+	if location == "" {
+		return serverUrl, nil
+	}
+	base, err := url.Parse(serverUrl)
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(location)
+	if err != nil {
+		return "", err
+	}
+	return base.ResolveReference(ref).String(), nil
+}
+func (self *YggdrasilClient) post(path string, value map[string]interface{}) (gjson.Result, error) {
+	b, status, err := PostJsonGotBytes(self.ServerUrl+path, value)
+	if err != nil {
+		return gjson.Result{}, err
+	}
+	r := gjson.ParseBytes(b)
+	if r.Get("error").Exists() {
+		return r, NewYggdrasilError(r.Get("error").String(), r.Get("errorMessage").String())
+	}
+	if status >= 300 {
+		return r, NewBadStatus(self.ServerUrl+path, status)
+	}
+	return r, nil
+}
+func (self *YggdrasilClient) Authenticate(username string, password string, clientToken string) (*YggdrasilSession, error) {
+	r, err := self.post("/authserver/authenticate", map[string]interface{}{
+		"agent": map[string]interface{}{
+			"name":    "Minecraft",
+			"version": 1,
+		},
+		"username":    username,
+		"password":    password,
+		"clientToken": clientToken,
+		"requestUser": false,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parseYggdrasilSession(r, clientToken)
+}
+func (self *YggdrasilClient) Refresh(accessToken string, clientToken string, profile *YggdrasilProfile) (*YggdrasilSession, error) {
+	value := map[string]interface{}{
+		"accessToken": accessToken,
+		"clientToken": clientToken,
+		"requestUser": false,
+	}
+	if profile != nil {
+		value["selectedProfile"] = profile
+	}
+	r, err := self.post("/authserver/refresh", value)
+	if err != nil {
+		return nil, err
+	}
+	return parseYggdrasilSession(r, clientToken)
+}
+func (self *YggdrasilClient) Validate(accessToken string, clientToken string) (bool, error) {
+	_, err := self.post("/authserver/validate", map[string]interface{}{
+		"accessToken": accessToken,
+		"clientToken": clientToken,
+	})
+	if _, ok := err.(*YggdrasilError); ok {
+		return false, nil
+	}
+	if b, ok := err.(*BadStatus); ok && b.Code == http.StatusForbidden {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+func (self *YggdrasilClient) Invalidate(accessToken string, clientToken string) error {
+	_, err := self.post("/authserver/invalidate", map[string]interface{}{
+		"accessToken": accessToken,
+		"clientToken": clientToken,
+	})
+	return err
+}
+func (self *YggdrasilClient) Signout(username string, password string) error {
+	_, err := self.post("/authserver/signout", map[string]interface{}{
+		"username": username,
+		"password": password,
+	})
+	return err
+}
+func (self *YggdrasilClient) Metadata() ([]byte, error) {
+	return GetByteInInternet(self.ServerUrl)
+}
+func parseYggdrasilSession(r gjson.Result, clientToken string) (*YggdrasilSession, error) {
+	if got := r.Get("clientToken").String(); got != "" && got != clientToken {
+		return nil, NewYggdrasilError("ClientTokenMismatch", "server returned clientToken "+got)
+	}
+	session := &YggdrasilSession{AccessToken: r.Get("accessToken").String(), ClientToken: clientToken}
+	for _, v := range r.Get("availableProfiles").Array() {
+		session.AvailableProfiles = append(session.AvailableProfiles, YggdrasilProfile{Id: v.Get("id").String(), Name: v.Get("name").String()})
+	}
+	if selected := r.Get("selectedProfile"); selected.Exists() {
+		session.SelectedProfile = &YggdrasilProfile{Id: selected.Get("id").String(), Name: selected.Get("name").String()}
+	}
+	return session, nil
+}
+func (self *McDownloader) AuthlibInjector() (string, error) {
+	dir := filepath.Join(self.McDir, "authlib-injector")
+	latestPath := filepath.Join(dir, "latest.json")
+	cached, cachedErr := os.ReadFile(latestPath)
+	if cachedErr == nil {
+		info, err := os.Stat(latestPath)
+		if err == nil && time.Since(info.ModTime()) < AuthlibInjectorCheckInterval {
+			if jar := authlibInjectorJar(dir, gjson.ParseBytes(cached)); jar != "" {
+				return jar, nil
+			}
+		}
+	}
+	latest, err := self.GetByteWithFailover("https://authlib-injector.yushi.moe/artifact/latest.json")
+	if err != nil {
+		if cachedErr == nil {
+			if jar := authlibInjectorJar(dir, gjson.ParseBytes(cached)); jar != "" {
+				println("authlib-injector metadata unavailable, using cached jar: " + err.Error())
+				return jar, nil
+			}
+		}
+		return "", err
+	}
+	r := gjson.ParseBytes(latest)
+	version := r.Get("version").String()
+	if version == "" {
+		return "", NewVersionNotFound("authlib-injector")
+	}
+	jar := filepath.Join(dir, "authlib-injector-"+version+".jar")
+	sha256 := r.Get("checksums.sha256").String()
+	if !IsFileSameHash(jar, "sha256", sha256, 0) {
+		err = self.DownloadWithFailover(jar, r.Get("download_url").String(), "sha256", sha256)
+		if err != nil {
+			return "", err
+		}
+	}
+	err = WriteBytes(latestPath, latest)
+	if err != nil {
+		return "", err
+	}
+	return jar, nil
+}
+func authlibInjectorJar(dir string, latest gjson.Result) string {
+	version := latest.Get("version").String()
+	if version == "" {
+		return ""
+	}
+	jar := filepath.Join(dir, "authlib-injector-"+version+".jar")
+	if !IsFileSameHash(jar, "sha256", latest.Get("checksums.sha256").String(), 0) {
+		return ""
+	}
+	return jar
+}
+func (self *McDownloader) AuthlibInjectorArgs(server string) ([]string, error) {
+	jar, err := self.AuthlibInjector()
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"-javaagent:" + jar + "=" + server}
+	metadata, err := NewYggdrasilClient(server).Metadata()
+	if err == nil {
+		args = append(args, "-Dauthlibinjector.yggdrasil.prefetched="+base64.StdEncoding.EncodeToString(metadata))
+	}
+	return args, nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAuthlibInjectorUsesFreshCache(t *testing.T) {
+	mcDir := t.TempDir()
+	dir := filepath.Join(mcDir, "authlib-injector")
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jarBytes := []byte("authlib-injector")
+	sum := sha256.Sum256(jarBytes)
+	jar := filepath.Join(dir, "authlib-injector-1.2.5.jar")
+	err = os.WriteFile(jar, jarBytes, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	latest := `{"version":"1.2.5","download_url":"http://127.0.0.1:0/unreachable.jar","checksums":{"sha256":"` + hex.EncodeToString(sum[:]) + `"}}`
+	err = os.WriteFile(filepath.Join(dir, "latest.json"), []byte(latest), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	downloader := &McDownloader{McDir: mcDir, Sources: []Source{Mojang}}
+	got, err := downloader.AuthlibInjector()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != jar {
+		t.Fatalf("AuthlibInjector() = %q, want %q", got, jar)
+	}
+}